@@ -11,11 +11,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// LiveRoomGiftAggregation 中的金额单位与 gift.LiveRoomGift 相同，均为金瓜子。
 type LiveRoomGiftAggregation struct {
 	ID        int64     `json:"-" gorm:"primaryKey"`
 	Price     int       `json:"price"`
+	PaidPrice int       `json:"paid_price"`
 	Count     int       `json:"count" gorm:"default:0"`
 	RoomID    int       `json:"room_id" gorm:"index"`
+	SessionID string    `json:"session_id" gorm:"index"`
 	Timestamp time.Time `json:"timestamp" gorm:"index"`
 }
 
@@ -76,18 +79,23 @@ func aggregate(db *gorm.DB) {
 			if startTime.IsZero() {
 				startTime = roomGift.CreatedAt.Truncate(time.Minute * 5)
 			}
-			if roomGift.CreatedAt.Truncate(time.Minute * 5).Equal(startTime) {
+			if roomGift.CreatedAt.Truncate(time.Minute*5).Equal(startTime) && roomGift.SessionID == data.SessionID {
 				data.Price += (roomGift.Price * roomGift.Count)
+				data.PaidPrice += (roomGift.PaidPrice * roomGift.Count)
 				data.Count += roomGift.Count
 				data.RoomID = roomGift.RoomID
 				data.Timestamp = startTime
 			} else {
 				startTime = roomGift.CreatedAt.Truncate(time.Minute * 5)
-				dataList = append(dataList, data)
+				if !data.Timestamp.IsZero() {
+					dataList = append(dataList, data)
+				}
 				data = LiveRoomGiftAggregation{}
 				data.Price += (roomGift.Price * roomGift.Count)
+				data.PaidPrice += (roomGift.PaidPrice * roomGift.Count)
 				data.Count += roomGift.Count
 				data.RoomID = roomGift.RoomID
+				data.SessionID = roomGift.SessionID
 				data.Timestamp = startTime
 			}
 			shouldDelete = append(shouldDelete, roomGift)
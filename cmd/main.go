@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
@@ -11,19 +12,26 @@ import (
 
 	"github.com/jannchie/zrrk/cmd/aggregate"
 	"github.com/jannchie/zrrk/zrrk"
-	"github.com/jannchie/zrrk/zrrk/plugin/gift"
+	_ "github.com/jannchie/zrrk/zrrk/plugin/gift"
+	_ "github.com/jannchie/zrrk/zrrk/plugin/metric"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
+	configPath := flag.String("config", "config.json", "机器人的配置文件，格式见 cmd/config.example.json")
+	flag.Parse()
 	log.SetFlags(log.LstdFlags)
 	log.SetOutput(os.Stdout)
 	err := godotenv.Load()
 	if err != nil {
 		log.Panic(err)
 	}
+	config, err := zrrk.LoadConfig(*configPath)
+	if err != nil {
+		log.Panic(err)
+	}
 	go func() {
 		heartBeatURL := os.Getenv("HEART_BEAT_URL")
 		for {
@@ -57,24 +65,65 @@ func main() {
 			}
 		}
 	}()
-	go taskSender(&runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND guard_num > 100`, time.Second/16, 0)
-	go taskSender(&runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND guard_num >= 1 AND guard_num < 100`, time.Second/10, 1)
-	go taskSender(&runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND live_status = 1`, time.Second/5, 1)
+	discovered := make(chan int, 100)
+	onDiscover := func(roomID int, source string) {
+		select {
+		case discovered <- roomID:
+		default:
+		}
+	}
+	dsn := os.Getenv("BILIBILI_DSN")
+	db, _ := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// 大航海超过 100 的直播间不论人气都保持连接，其余直播间按配置文件中的 stay_min_hot 退出
+	go discoverySender(config, &runningMap, discovered, time.Second/5, onDiscover)
+	go taskSender(config, db, &runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND guard_num > 100`, time.Second/16, true, onDiscover)
+	go taskSender(config, db, &runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND guard_num >= 1 AND guard_num < 100`, time.Second/10, false, onDiscover)
+	go taskSender(config, db, &runningMap, `SELECT room_id FROM livers WHERE room_id != 0 AND live_status = 1`, time.Second/5, false, onDiscover)
 	<-ctx.Done()
 }
 
-func taskSender(runningMap *sync.Map, sql string, interval time.Duration, stayMinHot int32) {
-	dsn := os.Getenv("BILIBILI_DSN")
-	db, _ := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	db.AutoMigrate(&gift.LiveRoomGift{})
-	giftPlugin := gift.New()
+func taskSender(config *zrrk.Config, db *gorm.DB, runningMap *sync.Map, sql string, interval time.Duration, keep bool, onDiscover func(int, string)) {
 	for {
-		createBotIfNotCreated(db, sql, runningMap, giftPlugin, interval, stayMinHot)
+		createBotIfNotCreated(config, db, sql, runningMap, interval, keep, onDiscover)
 		<-time.After(time.Second * 5)
 	}
 }
 
-func createBotIfNotCreated(db *gorm.DB, sql string, runningMap *sync.Map, giftPlugin *gift.GiftPlugin, interval time.Duration, stayMinHot int32) {
+// discoverySender 为跑马灯和 PK 中发现的直播间启动机器人，
+// 这些直播间不必在 livers 表中，弹幕冷清时会自动退出。
+func discoverySender(config *zrrk.Config, runningMap *sync.Map, discovered <-chan int, interval time.Duration, onDiscover func(int, string)) {
+	for roomID := range discovered {
+		if _, ok := runningMap.Load(roomID); ok {
+			continue
+		}
+		log.Println("Discovered Room:", roomID)
+		go startBot(config, runningMap, roomID, false, onDiscover)
+		<-time.After(interval)
+	}
+}
+
+// startBot 按配置文件创建直播间的机器人，keep 为真时不论人气都保持连接。
+func startBot(config *zrrk.Config, runningMap *sync.Map, roomID int, keep bool, onDiscover func(int, string)) {
+	m := sync.Mutex{}
+	bot, err := config.NewBot(&m, roomID)
+	if err != nil {
+		log.Printf("[ROOM %10d] 机器人创建失败: %v", roomID, err)
+		return
+	}
+	if keep {
+		bot.StayMinHot = 0
+	}
+	bot.OnDiscover = onDiscover
+	if _, loaded := runningMap.LoadOrStore(roomID, bot); loaded {
+		return
+	}
+	defer func() {
+		runningMap.Delete(roomID)
+	}()
+	bot.Connect()
+}
+
+func createBotIfNotCreated(config *zrrk.Config, db *gorm.DB, sql string, runningMap *sync.Map, interval time.Duration, keep bool, onDiscover func(int, string)) {
 	ctx := context.Background()
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	defer func() {
@@ -96,20 +145,7 @@ func createBotIfNotCreated(db *gorm.DB, sql string, runningMap *sync.Map, giftPl
 		if _, ok := runningMap.Load(roomID); ok {
 			continue
 		}
-		go func(roomID int) {
-			m := sync.Mutex{}
-			bot := zrrk.Default(&m, &zrrk.BotConfig{
-				RoomID:     roomID,
-				StayMinHot: stayMinHot,
-				LogLevel:   zrrk.LogErr,
-			})
-			runningMap.Store(roomID, bot)
-			defer func() {
-				runningMap.Delete(roomID)
-			}()
-			bot.AddPlugin(giftPlugin)
-			bot.Connect()
-		}(roomID)
+		go startBot(config, runningMap, roomID, keep, onDiscover)
 		<-time.After(interval)
 	}
 }
@@ -0,0 +1,54 @@
+package zrrk
+
+import (
+	"sort"
+	"sync"
+)
+
+type AdminList struct {
+	lock sync.RWMutex
+	uids map[int]struct{}
+}
+
+func NewAdminList() *AdminList {
+	return &AdminList{uids: map[int]struct{}{}}
+}
+
+func (l *AdminList) Set(uids []int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.uids = make(map[int]struct{}, len(uids))
+	for _, uid := range uids {
+		l.uids[uid] = struct{}{}
+	}
+}
+
+func (l *AdminList) Add(uid int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.uids[uid] = struct{}{}
+}
+
+func (l *AdminList) Remove(uid int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.uids, uid)
+}
+
+func (l *AdminList) Contains(uid int) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	_, ok := l.uids[uid]
+	return ok
+}
+
+func (l *AdminList) List() []int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	uids := make([]int, 0, len(l.uids))
+	for uid := range l.uids {
+		uids = append(uids, uid)
+	}
+	sort.Ints(uids)
+	return uids
+}
@@ -0,0 +1,132 @@
+package zrrk
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultComboTimeout = time.Second * 5
+
+// comboAggregator 按 batch_combo_id 合并连击，
+// 在最后一个包到达 timeout 之后发出汇总。Flush 之后机器人已经退出，之后到达的包会被忽略。
+type comboAggregator struct {
+	lock    sync.Mutex
+	timeout time.Duration
+	combos  map[string]*comboState
+	flushed bool
+}
+
+// comboState 分别记录 SEND_GIFT 的累计和 COMBO_SEND 中服务端的总数，
+// 两者到达的顺序不固定，汇总时取较大的一方，避免重复计算。
+type comboState struct {
+	data         ComboData
+	timer        *time.Timer
+	packetCount  int
+	packetAmount int
+	serverCount  int
+	serverAmount int
+}
+
+// sync 根据两方的统计更新汇总。combo_total_coin 的单位与礼物的 coin_type 相同，
+// 只收到 COMBO_SEND 时不知道礼物的单位，按金瓜子计。
+func (s *comboState) sync() {
+	s.data.Gift.Count = maxInt(s.packetCount, s.serverCount)
+	currency := s.data.Gift.Price.Currency
+	if currency == CURRENCY_NONE {
+		currency = CURRENCY_GOLD
+	}
+	s.data.Total = Money{Amount: maxInt(s.packetAmount, s.serverAmount), Currency: currency}
+}
+
+func newComboAggregator(timeout time.Duration) *comboAggregator {
+	if timeout <= 0 {
+		timeout = defaultComboTimeout
+	}
+	return &comboAggregator{
+		timeout: timeout,
+		combos:  map[string]*comboState{},
+	}
+}
+
+func (a *comboAggregator) state(id string, emit func(ComboData)) *comboState {
+	state, ok := a.combos[id]
+	if ok {
+		state.timer.Reset(a.timeout)
+		return state
+	}
+	state = &comboState{data: ComboData{BatchComboID: id, StartTime: time.Now()}}
+	state.timer = time.AfterFunc(a.timeout, func() {
+		a.lock.Lock()
+		if a.combos[id] != state {
+			a.lock.Unlock()
+			return
+		}
+		delete(a.combos, id)
+		data := state.data
+		a.lock.Unlock()
+		emit(data)
+	})
+	a.combos[id] = state
+	return state
+}
+
+func (a *comboAggregator) Add(g GiftData, emit func(ComboData)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.flushed {
+		return
+	}
+	state := a.state(g.BatchComboID, emit)
+	data := &state.data
+	data.RoomID = g.RoomID
+	data.User = g.User
+	data.SessionID = g.SessionID
+	if data.Packets == 0 {
+		data.Gift = g.Gift
+	}
+	data.Packets++
+	data.EndTime = time.Now()
+	state.packetCount += g.Gift.Count
+	state.packetAmount += g.Gift.Total().Amount
+	state.sync()
+}
+
+// Update 使用 COMBO_SEND 中服务端统计的总数，漏收 SEND_GIFT 时也能得到正确的汇总。
+func (a *comboAggregator) Update(msg ComboSend, emit func(ComboData)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.flushed {
+		return
+	}
+	state := a.state(msg.Data.BatchComboID, emit)
+	data := &state.data
+	if data.User.UID == 0 {
+		data.User = User{
+			Name: msg.Data.Uname,
+			UID:  msg.Data.UID,
+			Medal: Medal{
+				Title: msg.Data.MedalInfo.MedalName,
+				Level: msg.Data.MedalInfo.MedalLevel,
+			},
+		}
+		data.Gift.ID = msg.Data.GiftID
+		data.Gift.Name = msg.Data.GiftName
+	}
+	state.serverCount = maxInt(state.serverCount, msg.Data.BatchComboNum)
+	state.serverAmount = maxInt(state.serverAmount, msg.Data.ComboTotalCoin)
+	data.EndTime = time.Now()
+	state.sync()
+}
+
+// Flush 立即发出所有未结束的连击，之后不再接收新的包。
+func (a *comboAggregator) Flush(emit func(ComboData)) {
+	a.lock.Lock()
+	combos := a.combos
+	a.combos = map[string]*comboState{}
+	a.flushed = true
+	a.lock.Unlock()
+	for _, state := range combos {
+		state.timer.Stop()
+		emit(state.data)
+	}
+}
@@ -0,0 +1,140 @@
+package zrrk
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func comboSend(id string, num, total int) ComboSend {
+	var msg ComboSend
+	msg.Data.BatchComboID = id
+	msg.Data.BatchComboNum = num
+	msg.Data.ComboTotalCoin = total
+	return msg
+}
+
+func comboPacket(id string, price Money) GiftData {
+	return GiftData{BatchComboID: id, Gift: Gift{ID: 1, Count: 1, Price: price, PaidPrice: price}}
+}
+
+func flushCombos(a *comboAggregator) []ComboData {
+	var combos []ComboData
+	a.Flush(func(c ComboData) {
+		combos = append(combos, c)
+	})
+	return combos
+}
+
+func TestComboPacketsFirst(t *testing.T) {
+	a := newComboAggregator(time.Minute)
+	emit := func(ComboData) {}
+	a.Add(comboPacket("a", Gold(100)), emit)
+	a.Add(comboPacket("a", Gold(100)), emit)
+	a.Update(comboSend("a", 2, 200), emit)
+	combos := flushCombos(a)
+	if len(combos) != 1 || combos[0].Gift.Count != 2 || combos[0].Total != Gold(200) {
+		t.Fatal("unexpected combos: ", combos)
+	}
+}
+
+func TestComboSendFirst(t *testing.T) {
+	a := newComboAggregator(time.Minute)
+	emit := func(ComboData) {}
+	a.Update(comboSend("a", 2, 200), emit)
+	a.Add(comboPacket("a", Gold(100)), emit)
+	a.Add(comboPacket("a", Gold(100)), emit)
+	combos := flushCombos(a)
+	if len(combos) != 1 || combos[0].Gift.Count != 2 || combos[0].Total != Gold(200) {
+		t.Fatal("COMBO_SEND followed by SEND_GIFT should not double count: ", combos)
+	}
+
+	a = newComboAggregator(time.Minute)
+	a.Update(comboSend("b", 1, 100), emit)
+	a.Add(comboPacket("b", Silver(100)), emit)
+	combos = flushCombos(a)
+	if len(combos) != 1 || combos[0].Total != Silver(100) {
+		t.Fatal("currency should come from the gift: ", combos)
+	}
+
+	a = newComboAggregator(time.Minute)
+	a.Add(comboPacket("c", Gold(100)), emit)
+	a.Update(comboSend("c", 3, 300), emit)
+	combos = flushCombos(a)
+	if len(combos) != 1 || combos[0].Gift.Count != 3 || combos[0].Total != Gold(300) {
+		t.Fatal("server totals should cover missed packets: ", combos)
+	}
+}
+
+func TestComboKeepsFirstPrice(t *testing.T) {
+	a := newComboAggregator(time.Minute)
+	emit := func(ComboData) {}
+	a.Add(comboPacket("a", Gold(100)), emit)
+	a.Add(comboPacket("a", Gold(300)), emit)
+	combos := flushCombos(a)
+	if len(combos) != 1 || combos[0].Gift.Price != Gold(100) || combos[0].Total != Gold(400) {
+		t.Fatal("unit price should come from the first packet: ", combos)
+	}
+}
+
+func TestComboIgnoredAfterFlush(t *testing.T) {
+	a := newComboAggregator(time.Millisecond)
+	flushCombos(a)
+	emitted := make(chan ComboData, 1)
+	emit := func(c ComboData) { emitted <- c }
+	a.Add(comboPacket("a", Gold(100)), emit)
+	a.Update(comboSend("a", 1, 100), emit)
+	select {
+	case c := <-emitted:
+		t.Fatal("combo emitted after flush: ", c)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+type revenuePlugin struct {
+	roomPlugin
+}
+
+// Subscriptions 与 gift 插件相同。
+func (p *revenuePlugin) Subscriptions() []Subscription {
+	return []Subscription{
+		On(GiftData{}),
+		On(ComboData{}, Summarized()),
+	}
+}
+
+func TestComboRevenueStoredOnce(t *testing.T) {
+	packet := []byte(`{"cmd":"SEND_GIFT","data":{"action":"投喂","batch_combo_id":"batch","coin_type":"gold","giftId":1,"giftName":"礼物","num":1,"price":100,"uid":1,"uname":"user"}}`)
+	for _, mode := range []int{COMBO_MODE_PACKET, COMBO_MODE_SUMMARY, COMBO_MODE_BOTH} {
+		b := New()
+		b.Lock = &sync.Mutex{}
+		b.LogLevel = LogErr
+		b.ComboMode = mode
+		b.AddPlugin(&revenuePlugin{})
+		var msg SendGift
+		if err := json.Unmarshal(packet, &msg); err != nil {
+			t.Fatal(err)
+		}
+		b.HandleSendGift(msg)
+		b.HandleSendGift(msg)
+		b.HandleComboSend(comboSend("batch", 2, 200))
+		b.combos.Flush(b.emitCombo)
+		close(b.dataChan)
+		stored := 0
+		for event := range b.dataChan {
+			if !b.plugins[0].wants(event) {
+				continue
+			}
+			switch e := event.(type) {
+			case GiftData:
+				stored += e.Gift.Total().Gold()
+			case ComboData:
+				stored += e.Total.Gold()
+			}
+		}
+		if stored != 200 {
+			t.Fatalf("mode %d stored %d, want 200", mode, stored)
+		}
+	}
+}
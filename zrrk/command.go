@@ -0,0 +1,215 @@
+package zrrk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandContext 为一次命令调用。
+type CommandContext struct {
+	Danmaku DanmakuData
+	Command *Command
+	Args    []string
+	channel chan<- string
+}
+
+// Reply 通过插件的输出通道回复。
+func (c *CommandContext) Reply(text string) {
+	c.channel <- text
+}
+
+// Command 为一个弹幕命令。
+// Admin、GuardLevel 与 MedalLevel 为调用所需的权限，满足其中任意一个即可调用；都为零值时所有人都可以调用。
+// MedalLevel 只认本直播间的粉丝牌。MaxArgs 小于 0 时不限制参数个数。
+type Command struct {
+	Name           string
+	Aliases        []string
+	Description    string
+	MinArgs        int
+	MaxArgs        int
+	Admin          bool
+	GuardLevel     GuardLevel
+	MedalLevel     int
+	UserCooldown   time.Duration
+	GlobalCooldown time.Duration
+	Handler        func(ctx *CommandContext) error
+}
+
+func (c *Command) restricted() bool {
+	return c.Admin || c.GuardLevel != GUARD_LEVEL_NONE || c.MedalLevel > 0
+}
+
+// Allowed 判断弹幕的发送者是否有权限调用命令。
+func (c *Command) Allowed(d DanmakuData) bool {
+	if !c.restricted() {
+		return true
+	}
+	if c.Admin && d.IsAdmin {
+		return true
+	}
+	if c.GuardLevel != GUARD_LEVEL_NONE && d.GuardLevel != GUARD_LEVEL_NONE && !c.GuardLevel.Higher(d.GuardLevel) {
+		return true
+	}
+	medal := d.User.Medal
+	if c.MedalLevel > 0 && medal.RoomID == d.RoomID && medal.Level >= c.MedalLevel {
+		return true
+	}
+	return false
+}
+
+// CommandRouter 将以 Prefix 开头的弹幕分发给命令，本身是一个只订阅弹幕的插件。
+// 插件可以嵌入 CommandRouter 并在构造时注册命令。
+type CommandRouter struct {
+	RoomID   int
+	Prefix   string
+	lock     sync.Mutex
+	commands []*Command
+	index    map[string]*Command
+	// until 为命令或用户的冷却结束时间，已经结束的记录会被定期清理
+	until     map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewCommandRouter 创建命令路由，并注册列出所有命令的 help 命令。
+func NewCommandRouter(prefix string) *CommandRouter {
+	r := &CommandRouter{
+		Prefix: prefix,
+		index:  map[string]*Command{},
+		until:  map[string]time.Time{},
+	}
+	r.Handle(&Command{
+		Name:           "help",
+		Aliases:        []string{"帮助"},
+		MaxArgs:        -1,
+		GlobalCooldown: time.Second * 30,
+		Handler: func(ctx *CommandContext) error {
+			ctx.Reply(r.Help())
+			return nil
+		},
+	})
+	return r
+}
+
+// Handle 注册命令，名称与别名不区分大小写，重复的名称会覆盖之前的命令。
+func (r *CommandRouter) Handle(cmd *Command) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.commands = append(r.commands, cmd)
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		r.index[strings.ToLower(name)] = cmd
+	}
+}
+
+// Help 返回所有命令的名称以及 GetDescriptions 中的说明。
+func (r *CommandRouter) Help() string {
+	r.lock.Lock()
+	names := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		names = append(names, r.Prefix+cmd.Name)
+	}
+	r.lock.Unlock()
+	help := fmt.Sprintf("可用的命令：%s。", strings.Join(names, "、"))
+	return help + strings.Join(r.GetDescriptions(), "")
+}
+
+// Parse 解析弹幕，返回命令与参数，不是命令时返回 nil。
+func (r *CommandRouter) Parse(text string) (*Command, []string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, r.Prefix) {
+		return nil, nil
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, r.Prefix))
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	r.lock.Lock()
+	cmd := r.index[strings.ToLower(fields[0])]
+	r.lock.Unlock()
+	if cmd == nil {
+		return nil, nil
+	}
+	return cmd, fields[1:]
+}
+
+// cooldown 检查并记录命令的冷却，冷却中时返回 false。
+func (r *CommandRouter) cooldown(cmd *Command, uid int) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	r.prune(now)
+	globalKey := cmd.Name
+	userKey := fmt.Sprintf("%s:%d", cmd.Name, uid)
+	if now.Before(r.until[globalKey]) || now.Before(r.until[userKey]) {
+		return false
+	}
+	if cmd.GlobalCooldown > 0 {
+		r.until[globalKey] = now.Add(cmd.GlobalCooldown)
+	}
+	if cmd.UserCooldown > 0 {
+		r.until[userKey] = now.Add(cmd.UserCooldown)
+	}
+	return true
+}
+
+// prune 每分钟最多一次移除已经结束的冷却，调用时需持有 r.lock。
+func (r *CommandRouter) prune(now time.Time) {
+	if now.Before(r.nextPrune) {
+		return
+	}
+	r.nextPrune = now.Add(time.Minute)
+	for key, until := range r.until {
+		if !now.Before(until) {
+			delete(r.until, key)
+		}
+	}
+}
+
+func (r *CommandRouter) HandleDataErr(input interface{}, channel chan<- string) error {
+	data, ok := input.(DanmakuData)
+	if !ok || data.User.UID == 0 {
+		return nil
+	}
+	cmd, args := r.Parse(data.Text)
+	if cmd == nil || cmd.Handler == nil {
+		return nil
+	}
+	if len(args) < cmd.MinArgs || (cmd.MaxArgs >= 0 && len(args) > cmd.MaxArgs) {
+		return nil
+	}
+	if !cmd.Allowed(data) || !r.cooldown(cmd, data.User.UID) {
+		return nil
+	}
+	return cmd.Handler(&CommandContext{
+		Danmaku: data,
+		Command: cmd,
+		Args:    args,
+		channel: channel,
+	})
+}
+
+func (r *CommandRouter) HandleData(input interface{}, channel chan<- string) {
+	_ = r.HandleDataErr(input, channel)
+}
+
+// GetDescriptions 返回所有命令的说明。
+func (r *CommandRouter) GetDescriptions() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var descriptions []string
+	for _, cmd := range r.commands {
+		if cmd.Description != "" {
+			descriptions = append(descriptions, cmd.Description)
+		}
+	}
+	return descriptions
+}
+
+func (r *CommandRouter) SetRoom(id int) {
+	r.RoomID = id
+}
+
+func (r *CommandRouter) Subscriptions() []Subscription {
+	return []Subscription{On(DanmakuData{})}
+}
@@ -0,0 +1,71 @@
+package zrrk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommandRouter(t *testing.T) {
+	r := NewCommandRouter("06")
+	r.SetRoom(100)
+	calls := 0
+	r.Handle(&Command{
+		Name:         "运势",
+		Aliases:      []string{"RP"},
+		MaxArgs:      1,
+		MedalLevel:   5,
+		Admin:        true,
+		UserCooldown: time.Minute,
+		Handler: func(ctx *CommandContext) error {
+			calls++
+			return nil
+		},
+	})
+	out := make(chan string, 10)
+	fan := DanmakuData{RoomID: 100, User: User{UID: 1, Medal: Medal{Level: 5, RoomID: 100}}}
+	send := func(d DanmakuData, text string) {
+		d.Text = text
+		r.HandleData(d, out)
+	}
+
+	send(fan, "今天06运势怎么样")
+	send(fan, "06 rp a b")
+	if calls != 0 {
+		t.Fatal("should only match the prefix and argument count")
+	}
+	send(fan, "06rp")
+	send(fan, "06运势")
+	if calls != 1 {
+		t.Fatalf("user cooldown should apply, calls = %d", calls)
+	}
+	other := DanmakuData{RoomID: 100, User: User{UID: 2, Medal: Medal{Level: 20, RoomID: 200}}}
+	send(other, "06运势")
+	if calls != 1 {
+		t.Fatal("medal of other rooms should not count")
+	}
+	other.IsAdmin = true
+	send(other, "06运势")
+	if calls != 2 {
+		t.Fatal("admins should be allowed")
+	}
+	send(other, "06help")
+	if len(out) != 1 {
+		t.Fatal("help should reply")
+	}
+}
+
+func TestCommandCooldownPrune(t *testing.T) {
+	r := NewCommandRouter("!")
+	cmd := &Command{Name: "ping", UserCooldown: time.Millisecond}
+	for uid := 1; uid <= 3; uid++ {
+		r.cooldown(cmd, uid)
+	}
+	if len(r.until) != 3 {
+		t.Fatalf("until = %v", r.until)
+	}
+	time.Sleep(time.Millisecond * 5)
+	r.nextPrune = time.Time{}
+	if !r.cooldown(cmd, 4) || len(r.until) != 1 {
+		t.Fatalf("expired cooldowns should be pruned: %v", r.until)
+	}
+}
@@ -0,0 +1,325 @@
+package zrrk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Duration 在配置文件中写作 "5s"、"1m" 等形式。
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// QueueSettings 为配置文件中的队列设置，Policy 为 drop_oldest、drop_newest、block 或 spill。
+type QueueSettings struct {
+	Size     int    `json:"size"`
+	Policy   string `json:"policy"`
+	SpillDir string `json:"spill_dir"`
+}
+
+var queuePolicies = map[string]int{
+	"":            0,
+	"drop_oldest": QUEUE_DROP_OLDEST,
+	"drop_newest": QUEUE_DROP_NEWEST,
+	"block":       QUEUE_BLOCK,
+	"spill":       QUEUE_SPILL,
+}
+
+func (s QueueSettings) config() (QueueConfig, error) {
+	policy, ok := queuePolicies[s.Policy]
+	if !ok {
+		return QueueConfig{}, fmt.Errorf("未知的队列策略: %s", s.Policy)
+	}
+	return QueueConfig{Size: s.Size, Policy: policy, SpillDir: s.SpillDir}, nil
+}
+
+// PluginConfig 为一个插件的配置，Enabled 为空时表示启用。
+type PluginConfig struct {
+	Enabled  *bool           `json:"enabled"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+func (p PluginConfig) enabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// SinkConfig 为一个输出的配置，Type 为 RegisterSink 登记的名称。
+type SinkConfig struct {
+	Type     string          `json:"type"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+// RoomConfig 为一个直播间的配置，为空的字段使用默认配置。
+type RoomConfig struct {
+	RoomID            int                     `json:"room_id"`
+	ProxyURL          *string                 `json:"proxy_url"`
+	StayMinHot        *int32                  `json:"stay_min_hot"`
+	LogLevel          *int                    `json:"log_level"`
+	ComboMode         *int                    `json:"combo_mode"`
+	ComboTimeout      *Duration               `json:"combo_timeout"`
+	PluginTimeout     *Duration               `json:"plugin_timeout"`
+	PluginMaxFailures *int                    `json:"plugin_max_failures"`
+	Queue             *QueueSettings          `json:"queue"`
+	Plugins           map[string]PluginConfig `json:"plugins"`
+	Sinks             []SinkConfig            `json:"sinks"`
+}
+
+// Config 为配置文件。数据库等外部资源通过插件的 settings 配置，例如 gift 插件的 dsn。
+type Config struct {
+	Defaults RoomConfig   `json:"defaults"`
+	Rooms    []RoomConfig `json:"rooms"`
+}
+
+// PluginBuilder 根据配置为直播间创建插件，共享的插件可以返回同一个实例。
+type PluginBuilder func(roomID int, settings json.RawMessage) (BotPlugin, error)
+
+// SinkBuilder 根据配置创建输出。
+type SinkBuilder func(settings json.RawMessage) (Sink, error)
+
+var (
+	registryLock sync.RWMutex
+	pluginByName = map[string]PluginBuilder{}
+	sinkByName   = map[string]SinkBuilder{
+		"file": func(settings json.RawMessage) (Sink, error) {
+			sink := &FileSink{Path: "../message.txt"}
+			if len(settings) > 0 {
+				if err := json.Unmarshal(settings, sink); err != nil {
+					return nil, err
+				}
+			}
+			return sink, nil
+		},
+		"log": func(settings json.RawMessage) (Sink, error) {
+			return &LogSink{}, nil
+		},
+	}
+)
+
+// RegisterPlugin 登记插件，配置文件中以 name 引用。插件包通常在 init 中登记自己。
+func RegisterPlugin(name string, builder PluginBuilder) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	pluginByName[name] = builder
+}
+
+// DecodeSettings 解码插件或输出的设置，设置为空时保留 v 中的默认值。
+func DecodeSettings(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// RegisterSharedPlugin 登记所有直播间共享同一个实例的插件，实例在第一次被使用时创建。
+// 共享的实例只有一份设置，之后的直播间的设置必须与第一次相同，否则返回错误。
+func RegisterSharedPlugin(name string, builder func(settings json.RawMessage) (BotPlugin, error)) {
+	var (
+		lock     sync.Mutex
+		plugin   BotPlugin
+		settings interface{}
+	)
+	RegisterPlugin(name, func(roomID int, raw json.RawMessage) (BotPlugin, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		var current interface{}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &current); err != nil {
+				return nil, err
+			}
+		}
+		if plugin != nil {
+			if !reflect.DeepEqual(current, settings) {
+				return nil, fmt.Errorf("共享插件 %s 的设置与已创建的实例不同", name)
+			}
+			return plugin, nil
+		}
+		p, err := builder(raw)
+		if err != nil {
+			return nil, err
+		}
+		plugin, settings = p, current
+		return plugin, nil
+	})
+}
+
+// RegisterSink 登记输出，配置文件中以 name 引用。
+func RegisterSink(name string, builder SinkBuilder) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	sinkByName[name] = builder
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Room 返回合并了默认配置的直播间配置，不在 Rooms 中的直播间只使用默认配置。
+func (c *Config) Room(roomID int) RoomConfig {
+	room := c.Defaults
+	room.RoomID = roomID
+	room.Plugins = map[string]PluginConfig{}
+	for name, plugin := range c.Defaults.Plugins {
+		room.Plugins[name] = plugin
+	}
+	for _, r := range c.Rooms {
+		if r.RoomID != roomID {
+			continue
+		}
+		if r.ProxyURL != nil {
+			room.ProxyURL = r.ProxyURL
+		}
+		if r.StayMinHot != nil {
+			room.StayMinHot = r.StayMinHot
+		}
+		if r.LogLevel != nil {
+			room.LogLevel = r.LogLevel
+		}
+		if r.ComboMode != nil {
+			room.ComboMode = r.ComboMode
+		}
+		if r.ComboTimeout != nil {
+			room.ComboTimeout = r.ComboTimeout
+		}
+		if r.PluginTimeout != nil {
+			room.PluginTimeout = r.PluginTimeout
+		}
+		if r.PluginMaxFailures != nil {
+			room.PluginMaxFailures = r.PluginMaxFailures
+		}
+		if r.Queue != nil {
+			room.Queue = r.Queue
+		}
+		for name, plugin := range r.Plugins {
+			if plugin.Enabled == nil {
+				plugin.Enabled = room.Plugins[name].Enabled
+			}
+			if plugin.Settings == nil {
+				plugin.Settings = room.Plugins[name].Settings
+			}
+			room.Plugins[name] = plugin
+		}
+		if r.Sinks != nil {
+			room.Sinks = r.Sinks
+		}
+	}
+	return room
+}
+
+// RoomIDs 返回配置中的所有直播间。
+func (c *Config) RoomIDs() []int {
+	ids := make([]int, 0, len(c.Rooms))
+	for _, r := range c.Rooms {
+		ids = append(ids, r.RoomID)
+	}
+	return ids
+}
+
+// BotConfig 返回直播间对应的 BotConfig。
+func (r *RoomConfig) BotConfig() (*BotConfig, error) {
+	config := &BotConfig{RoomID: r.RoomID}
+	if r.ProxyURL != nil {
+		config.ProxyURL = *r.ProxyURL
+	}
+	if r.StayMinHot != nil {
+		config.StayMinHot = *r.StayMinHot
+	}
+	if r.LogLevel != nil {
+		config.LogLevel = *r.LogLevel
+	}
+	if r.ComboMode != nil {
+		config.ComboMode = *r.ComboMode
+	}
+	if r.ComboTimeout != nil {
+		config.ComboTimeout = time.Duration(*r.ComboTimeout)
+	}
+	if r.PluginTimeout != nil {
+		config.PluginTimeout = time.Duration(*r.PluginTimeout)
+	}
+	if r.PluginMaxFailures != nil {
+		config.PluginMaxFailures = *r.PluginMaxFailures
+	}
+	if r.Queue != nil {
+		queue, err := r.Queue.config()
+		if err != nil {
+			return nil, err
+		}
+		config.PluginQueue = queue
+	}
+	return config, nil
+}
+
+// NewBot 按配置创建直播间的机器人，并添加启用的插件和输出。插件按名称顺序添加。
+func (c *Config) NewBot(m *sync.Mutex, roomID int) (*Bot, error) {
+	room := c.Room(roomID)
+	config, err := room.BotConfig()
+	if err != nil {
+		return nil, err
+	}
+	b := Default(m, config)
+	names := make([]string, 0, len(room.Plugins))
+	for name := range room.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for _, name := range names {
+		plugin := room.Plugins[name]
+		if !plugin.enabled() {
+			continue
+		}
+		builder, ok := pluginByName[name]
+		if !ok {
+			return nil, fmt.Errorf("未登记的插件: %s", name)
+		}
+		p, err := builder(roomID, plugin.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("插件 %s 创建失败: %w", name, err)
+		}
+		b.AddPlugin(p)
+	}
+	for _, s := range room.Sinks {
+		builder, ok := sinkByName[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("未登记的输出: %s", s.Type)
+		}
+		sink, err := builder(s.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("输出 %s 创建失败: %w", s.Type, err)
+		}
+		b.AddSink(sink)
+	}
+	return b, nil
+}
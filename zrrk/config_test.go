@@ -0,0 +1,90 @@
+package zrrk
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfigNewBot(t *testing.T) {
+	RegisterPlugin("test_room", func(roomID int, settings json.RawMessage) (BotPlugin, error) {
+		return &roomPlugin{}, nil
+	})
+	config, err := ParseConfig([]byte(`{
+		"defaults": {
+			"stay_min_hot": 1,
+			"plugin_timeout": "3s",
+			"queue": {"size": 10, "policy": "drop_newest"},
+			"plugins": {"test_room": {}}
+		},
+		"rooms": [
+			{"room_id": 1, "stay_min_hot": 5, "plugins": {"test_room": {"enabled": false}}},
+			{"room_id": 2, "sinks": [{"type": "log"}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := sync.Mutex{}
+	a, err := config.NewBot(&m, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.StayMinHot != 5 || a.PluginTimeout != time.Second*3 || len(a.plugins) != 0 {
+		t.Fatalf("room 1 = %d %v %d", a.StayMinHot, a.PluginTimeout, len(a.plugins))
+	}
+	b, err := config.NewBot(&m, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.StayMinHot != 1 || len(b.plugins) != 1 || len(b.sinks) != 1 {
+		t.Fatalf("room 2 = %d %d %d", b.StayMinHot, len(b.plugins), len(b.sinks))
+	}
+	if got := b.plugins[0].plugin.(*roomPlugin).roomID; got != 2 {
+		t.Fatalf("plugin room = %d", got)
+	}
+	if b.PluginQueue.Policy != QUEUE_DROP_NEWEST || b.PluginQueue.Size != 10 {
+		t.Fatalf("queue = %+v", b.PluginQueue)
+	}
+	if _, err := config.NewBot(&m, 3); err != nil {
+		t.Fatal(err)
+	}
+	bad, _ := ParseConfig([]byte(`{"defaults": {"plugins": {"missing": {}}}}`))
+	if _, err := bad.NewBot(&m, 1); err == nil {
+		t.Fatal("expected error for unregistered plugin")
+	}
+}
+
+func TestRegisterSharedPlugin(t *testing.T) {
+	builds := 0
+	RegisterSharedPlugin("test_shared", func(settings json.RawMessage) (BotPlugin, error) {
+		builds++
+		return &roomPlugin{}, nil
+	})
+	config, err := ParseConfig([]byte(`{
+		"defaults": {"plugins": {"test_shared": {"settings": {"a": 1, "b": 2}}}},
+		"rooms": [
+			{"room_id": 2, "plugins": {"test_shared": {"settings": {"b": 2, "a": 1}}}},
+			{"room_id": 3, "plugins": {"test_shared": {"settings": {"a": 2}}}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := sync.Mutex{}
+	a, err := config.NewBot(&m, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := config.NewBot(&m, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if builds != 1 || a.plugins[0].plugin != b.plugins[0].plugin {
+		t.Fatalf("builds = %d", builds)
+	}
+	if _, err := config.NewBot(&m, 3); err == nil {
+		t.Fatal("expected error for different settings")
+	}
+}
@@ -22,6 +22,79 @@ const (
 )
 
 const (
-	INTERACT_ENTER  = 1
-	INTERACT_FOLLOW = 2
+	INTERACT_ENTER          = 1
+	INTERACT_FOLLOW         = 2
+	INTERACT_SHARE          = 3
+	INTERACT_SPECIAL_FOLLOW = 4
+	INTERACT_MUTUAL_FOLLOW  = 5
+)
+
+const (
+	LIVE_STATUS_PREPARING = 0
+	LIVE_STATUS_LIVE      = 1
+	LIVE_STATUS_ROUND     = 2
+	LIVE_STATUS_CUT_OFF   = 3
+)
+
+const (
+	MODERATION_BLOCK        = 1
+	MODERATION_ADMIN_ADD    = 2
+	MODERATION_ADMIN_REVOKE = 3
+	MODERATION_SILENT_ON    = 4
+	MODERATION_SILENT_OFF   = 5
+)
+
+const (
+	MODERATION_OPERATOR_ADMIN  = 1
+	MODERATION_OPERATOR_ANCHOR = 2
+)
+
+const (
+	SILENT_SCOPE_LEVEL  = "level"
+	SILENT_SCOPE_MEDAL  = "medal"
+	SILENT_SCOPE_MEMBER = "member"
+)
+
+const (
+	COMBO_MODE_PACKET  = 0
+	COMBO_MODE_SUMMARY = 1
+	COMBO_MODE_BOTH    = 2
+)
+
+const (
+	RANK_CHANGE_ENTER = 1
+	RANK_CHANGE_LEAVE = 2
+	RANK_CHANGE_MOVE  = 3
+)
+
+const (
+	GOODS_LISTED    = 1
+	GOODS_EXPLAINED = 2
+)
+
+const (
+	COSTREAM_VOICE = "voice"
+	COSTREAM_VIDEO = "video"
+)
+
+const (
+	COSTREAM_START = 1
+	COSTREAM_END   = 2
+)
+
+const (
+	GAME_START = 1
+	GAME_END   = 2
+)
+
+const (
+	DISCOVER_NOTICE = "notice"
+	DISCOVER_PK     = "pk"
+)
+
+const (
+	MILESTONE_WISH_REACHED      = "wish_reached"
+	MILESTONE_GUARD_ACHIEVEMENT = "guard_achievement"
+	MILESTONE_ROOM_SKIN         = "room_skin"
+	MILESTONE_ACTIVITY_BANNER   = "activity_banner"
 )
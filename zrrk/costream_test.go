@@ -0,0 +1,58 @@
+package zrrk
+
+import (
+	"sync"
+	"testing"
+)
+
+func drainCoStreams(b *Bot) []CoStreamData {
+	var events []CoStreamData
+	for {
+		select {
+		case e := <-b.dataChan:
+			if cs, ok := e.(CoStreamData); ok {
+				events = append(events, cs)
+			}
+		default:
+			return events
+		}
+	}
+}
+
+func TestVideoCoStreamEndsOnce(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr
+	var start VideoConnectionJoinStart
+	start.Data.ChannelID = "channel"
+	start.Data.InvitedUID = 2
+	b.HandleVideoConnectionJoinStart(start)
+	if events := drainCoStreams(b); len(events) != 0 {
+		t.Fatal("co-stream should not start before going live: ", events)
+	}
+
+	b.session.Start(Session{RoomID: 1, LiveKey: "key"})
+	b.HandleVideoConnectionJoinStart(start)
+	b.HandleVideoConnectionJoinStart(start)
+	var msg VideoConnectionMsg
+	msg.Data.ChannelID = "channel"
+	b.HandleVideoConnectionMsg(msg)
+	var end VideoConnectionJoinEnd
+	end.Data.ChannelID = "channel"
+	b.HandleVideoConnectionJoinEnd(end)
+	events := drainCoStreams(b)
+	if len(events) != 2 || events[0].Action != COSTREAM_START || events[1].Action != COSTREAM_END {
+		t.Fatal("want exactly one START and one END: ", events)
+	}
+	if events[1].CoStream.PartnerUID != 2 {
+		t.Fatal("END should carry the recorded co-stream: ", events[1])
+	}
+
+	var voice VoiceJoinStatus
+	voice.Data.Status = 0
+	b.HandleVoiceJoinStatus(voice)
+	b.HandleVoiceJoinStatus(voice)
+	if events := drainCoStreams(b); len(events) != 0 {
+		t.Fatal("voice status without a co-stream should not end anything: ", events)
+	}
+}
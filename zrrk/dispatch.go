@@ -0,0 +1,308 @@
+package zrrk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const defaultPluginMaxFailures = 10
+
+var (
+	ErrPluginTimeout = errors.New("插件处理超时")
+	ErrPluginBusy    = errors.New("插件仍在处理超时的事件")
+)
+
+// ErrorPlugin 由需要上报错误的插件实现，实现后 HandleDataErr 会代替 HandleData 被调用。
+type ErrorPlugin interface {
+	HandleDataErr(data interface{}, channel chan<- string) error
+}
+
+// TimeoutPlugin 由需要单独设置处理时限的插件实现，返回 0 表示不限时。
+type TimeoutPlugin interface {
+	Timeout() time.Duration
+}
+
+// PluginStats 为插件的调用统计，Failures 为连续失败的次数。
+// Skipped 为超时的调用仍在执行时被跳过的事件，不计入 Calls 和 Failures。
+type PluginStats struct {
+	Name     string     `json:"name"`
+	Calls    int64      `json:"calls"`
+	Errors   int64      `json:"errors"`
+	Panics   int64      `json:"panics"`
+	Timeouts int64      `json:"timeouts"`
+	Skipped  int64      `json:"skipped"`
+	Failures int64      `json:"failures"`
+	Disabled bool       `json:"disabled"`
+	Queue    QueueStats `json:"queue"`
+}
+
+type pluginEntry struct {
+	plugin BotPlugin
+	lock   sync.Mutex
+	stats  PluginStats
+	queue  *eventQueue
+	subs   []Subscription
+	// running 为超时后仍未返回的调用，只由插件自己的协程访问
+	running chan error
+}
+
+func newPluginEntry(plugin BotPlugin) *pluginEntry {
+	e := &pluginEntry{
+		plugin: plugin,
+		stats:  PluginStats{Name: fmt.Sprintf("%T", plugin)},
+	}
+	if p, ok := plugin.(SubscribingPlugin); ok {
+		e.subs = p.Subscriptions()
+		if e.subs == nil {
+			e.subs = []Subscription{}
+		}
+	}
+	return e
+}
+
+// wants 判断插件是否订阅了事件，未声明订阅的插件接收所有事件。
+func (e *pluginEntry) wants(event interface{}) bool {
+	if e.subs == nil {
+		return true
+	}
+	kind := EventKind(event)
+	for i := range e.subs {
+		if e.subs[i].Kind == kind && e.subs[i].match(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *pluginEntry) Stats() PluginStats {
+	e.lock.Lock()
+	stats := e.stats
+	e.lock.Unlock()
+	if e.queue != nil {
+		stats.Queue = e.queue.Stats()
+	}
+	return stats
+}
+
+func (e *pluginEntry) disabled() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.stats.Disabled
+}
+
+// record 记录一次调用的结果，返回插件是否因此被停用。
+func (e *pluginEntry) record(err error, maxFailures int) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if errors.Is(err, ErrPluginBusy) {
+		e.stats.Skipped++
+		return false
+	}
+	e.stats.Calls++
+	if err == nil {
+		e.stats.Failures = 0
+		return false
+	}
+	var p panicError
+	switch {
+	case errors.As(err, &p):
+		e.stats.Panics++
+	case errors.Is(err, ErrPluginTimeout):
+		e.stats.Timeouts++
+	default:
+		e.stats.Errors++
+	}
+	e.stats.Failures++
+	if maxFailures > 0 && e.stats.Failures >= int64(maxFailures) && !e.stats.Disabled {
+		e.stats.Disabled = true
+		return true
+	}
+	return false
+}
+
+// recordLate 记录超时的调用最终的结果。超时已经计为一次失败，
+// 最终成功时清零连续失败的次数，失败时只计入对应的统计。
+func (e *pluginEntry) recordLate(err error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	var p panicError
+	switch {
+	case err == nil:
+		e.stats.Failures = 0
+	case errors.As(err, &p):
+		e.stats.Panics++
+	default:
+		e.stats.Errors++
+	}
+}
+
+type panicError struct {
+	value interface{}
+}
+
+func (p panicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.value)
+}
+
+// handle 调用插件并恢复其中的 panic。
+func (e *pluginEntry) handle(data interface{}, channel chan<- string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError{value: r}
+		}
+	}()
+	if p, ok := e.plugin.(ErrorPlugin); ok {
+		return p.HandleDataErr(data, channel)
+	}
+	e.plugin.HandleData(data, channel)
+	return nil
+}
+
+// call 在时限内调用插件，超时后不再等待，插件的调用会在后台继续执行。
+// 超时的调用返回之前不会再调用该插件，期间的事件被跳过，插件不会与自己并发执行。
+func (e *pluginEntry) call(data interface{}, channel chan<- string, timeout time.Duration) error {
+	if e.running != nil {
+		select {
+		case err := <-e.running:
+			e.running = nil
+			e.recordLate(err)
+		default:
+			return ErrPluginBusy
+		}
+	}
+	if p, ok := e.plugin.(TimeoutPlugin); ok {
+		timeout = p.Timeout()
+	}
+	if timeout <= 0 {
+		return e.handle(data, channel)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- e.handle(data, channel)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		e.running = done
+		return ErrPluginTimeout
+	}
+}
+
+func (b *Bot) dispatch(e *pluginEntry, data interface{}) {
+	if e.disabled() {
+		return
+	}
+	err := e.call(data, b.outChannel, b.PluginTimeout)
+	maxFailures := b.PluginMaxFailures
+	if maxFailures == 0 {
+		maxFailures = defaultPluginMaxFailures
+	}
+	if e.record(err, maxFailures) {
+		b.ERROR(fmt.Sprintf("插件 %s 连续失败 %d 次，已停用", e.stats.Name, maxFailures))
+		return
+	}
+	if err != nil && !errors.Is(err, ErrPluginBusy) {
+		b.ERROR(fmt.Sprintf("插件 %s 处理 %T 失败: ", e.stats.Name, data), err)
+	}
+}
+
+// PluginStats 返回所有插件的调用统计。
+func (b *Bot) PluginStats() []PluginStats {
+	stats := make([]PluginStats, 0, len(b.plugins))
+	for _, e := range b.plugins {
+		stats = append(stats, e.Stats())
+	}
+	return stats
+}
+
+// queueConfig 合并插件自身与机器人的队列配置。
+func (b *Bot) queueConfig(e *pluginEntry) QueueConfig {
+	config := b.PluginQueue
+	if p, ok := e.plugin.(QueuePlugin); ok {
+		own := p.QueueConfig()
+		if own.Size > 0 {
+			config.Size = own.Size
+		}
+		if own.Policy != 0 {
+			config.Policy = own.Policy
+		}
+		if own.SpillDir != "" {
+			config.SpillDir = own.SpillDir
+		}
+	}
+	if config.SpillDir == "" {
+		config.SpillDir = os.TempDir()
+	}
+	return config
+}
+
+// startDispatch 为每个插件启动独立的队列和协程，接收消息不会被慢的插件拖住。
+// 返回的函数会等待队列中剩余的事件处理完毕。
+func (b *Bot) startDispatch() func() {
+	b.kinds = b.subscribedKinds()
+	var wg sync.WaitGroup
+	for i, e := range b.plugins {
+		config := b.queueConfig(e)
+		spillPath := filepath.Join(config.SpillDir, fmt.Sprintf("zrrk-%d-%d.jsonl", b.RoomID, i))
+		e.queue = newEventQueue(config, spillPath)
+		wg.Add(1)
+		go func(e *pluginEntry) {
+			defer wg.Done()
+			for {
+				data, ok := e.queue.pop()
+				if !ok {
+					return
+				}
+				b.dispatch(e, data)
+			}
+		}(e)
+	}
+	done := make(chan struct{})
+	fanned := make(chan struct{})
+	go func() {
+		defer close(fanned)
+		for {
+			select {
+			case data := <-b.dataChan:
+				b.fanOut(data)
+			case <-done:
+				for len(b.dataChan) > 0 {
+					b.fanOut(<-b.dataChan)
+				}
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-fanned
+		for _, e := range b.plugins {
+			e.queue.close()
+		}
+		wg.Wait()
+	}
+}
+
+func (b *Bot) fanOut(data interface{}) {
+	data, ok := b.applyMiddlewares(data)
+	if !ok {
+		return
+	}
+	for _, e := range b.plugins {
+		if e.disabled() || !e.wants(data) {
+			continue
+		}
+		if e.queue.push(data) {
+			if dropped := e.queue.Stats().Dropped; dropped%1000 == 1 {
+				b.WARNING(fmt.Sprintf("插件 %s 的队列已满，共丢弃 %d 个事件", e.stats.Name, dropped))
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package zrrk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var eventTypes = map[string]reflect.Type{}
+
+func init() {
+	RegisterEvent(
+		GiftData{}, ComboData{}, DanmakuData{}, SCData{}, SCDeleteData{}, SCExpireData{},
+		SCTranslationData{}, InteractData{}, EntryEffectData{}, NoticeData{}, LiveStatusData{}, RoomChangeData{},
+		ModerationData{}, AdminListData{}, GuardEvent{}, OnlineRankData{}, OnlineRankChangeData{},
+		WatchedData{}, LikeData{}, PopularityData{}, GoodsData{}, ShoppingCartData{},
+		ShoppingBubblesData{}, HotBuyData{}, GotoBuyData{}, CoStreamData{}, WishListData{},
+		GuardAchievementData{}, RoomSkinData{}, ActivityBannerData{}, MilestoneData{},
+		GameData{}, GameActionData{},
+	)
+}
+
+// RegisterEvent 登记事件类型，登记后的事件才能被写入磁盘并还原。
+// 插件自定义的事件也可以在这里登记。
+func RegisterEvent(events ...interface{}) {
+	for _, e := range events {
+		t := reflect.TypeOf(e)
+		eventTypes[t.Name()] = t
+	}
+}
+
+// EventKinds 返回所有已登记的事件类型名。
+func EventKinds() []string {
+	kinds := make([]string, 0, len(eventTypes))
+	for kind := range eventTypes {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// EventKind 返回事件的类型名，例如 GiftData。
+func EventKind(event interface{}) string {
+	t := reflect.TypeOf(event)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+type encodedEvent struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// EncodeEvent 将事件编码为一行 {"kind": ..., "data": ...} 形式的 JSON，不含换行符。
+func EncodeEvent(event interface{}) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedEvent{Kind: EventKind(event), Data: data})
+}
+
+// DecodeEvent 还原 EncodeEvent 编码的已登记事件。
+func DecodeEvent(line []byte) (interface{}, error) {
+	var e encodedEvent
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, err
+	}
+	t, ok := eventTypes[e.Kind]
+	if !ok {
+		return nil, fmt.Errorf("未登记的事件类型: %s", e.Kind)
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(e.Data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,133 @@
+package zrrk
+
+import (
+	"sync"
+	"time"
+)
+
+// gameState 记录直播间正在进行的开放平台玩法，
+// 玩法进行中收到的礼物会归属到该玩法。
+type gameState struct {
+	lock    sync.RWMutex
+	current GameData
+	playing bool
+}
+
+func (g *gameState) Start(game GameData) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.current = game
+	g.playing = true
+}
+
+func (g *gameState) End() (GameData, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	playing := g.playing
+	g.playing = false
+	return g.current, playing
+}
+
+func (g *gameState) Current() (GameData, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.current, g.playing
+}
+
+func (g *gameState) Code() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if !g.playing {
+		return ""
+	}
+	return g.current.GameCode
+}
+
+// GAME_CODE_INTERACTIVE 为互动玩法中送出、但不知道玩法代码的礼物的 GameCode。
+const GAME_CODE_INTERACTIVE = "interactive"
+
+// gameLinkWindow 为互动玩法中的付费礼物与对应的 SEND_GIFT 互相等待的时间。
+const gameLinkWindow = time.Second * 10
+
+// gameGiftKey 为互动玩法礼物与 SEND_GIFT 共有的字段，两者没有共同的 ID。
+type gameGiftKey struct {
+	uid    int
+	giftID int
+	count  int
+}
+
+type gameGiftWait struct {
+	value string
+	at    time.Time
+}
+
+// gameGiftLinker 将互动玩法中的付费礼物与对应的 SEND_GIFT 对应起来，两者到达的顺序不固定。
+// 玩法礼物先到时，之后的 SEND_GIFT 直接带上玩法代码；
+// SEND_GIFT 先到时，之后的玩法礼物带上该 SEND_GIFT 的 tid。
+type gameGiftLinker struct {
+	lock   sync.Mutex
+	window time.Duration
+	games  map[gameGiftKey][]gameGiftWait
+	gifts  map[gameGiftKey][]gameGiftWait
+}
+
+func newGameGiftLinker(window time.Duration) *gameGiftLinker {
+	return &gameGiftLinker{
+		window: window,
+		games:  map[gameGiftKey][]gameGiftWait{},
+		gifts:  map[gameGiftKey][]gameGiftWait{},
+	}
+}
+
+// take 取出 key 下最早的未过期的记录，并移除过期的记录。
+func (l *gameGiftLinker) take(waits map[gameGiftKey][]gameGiftWait, key gameGiftKey, now time.Time) (string, bool) {
+	list := waits[key]
+	for len(list) > 0 && now.Sub(list[0].at) > l.window {
+		list = list[1:]
+	}
+	if len(list) == 0 {
+		delete(waits, key)
+		return "", false
+	}
+	value := list[0].value
+	if list = list[1:]; len(list) == 0 {
+		delete(waits, key)
+	} else {
+		waits[key] = list
+	}
+	return value, true
+}
+
+func (l *gameGiftLinker) prune(now time.Time) {
+	for _, waits := range []map[gameGiftKey][]gameGiftWait{l.games, l.gifts} {
+		for key, list := range waits {
+			if now.Sub(list[len(list)-1].at) > l.window {
+				delete(waits, key)
+			}
+		}
+	}
+}
+
+// OnGift 记录一个付费的 SEND_GIFT，返回已经等待中的玩法代码。
+func (l *gameGiftLinker) OnGift(key gameGiftKey, tid string, now time.Time) (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.prune(now)
+	if code, ok := l.take(l.games, key, now); ok {
+		return code, true
+	}
+	l.gifts[key] = append(l.gifts[key], gameGiftWait{value: tid, at: now})
+	return "", false
+}
+
+// OnGame 记录一个互动玩法中的付费礼物，返回已经发出的对应 SEND_GIFT 的 tid。
+func (l *gameGiftLinker) OnGame(key gameGiftKey, code string, now time.Time) (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.prune(now)
+	if tid, ok := l.take(l.gifts, key, now); ok {
+		return tid, true
+	}
+	l.games[key] = append(l.games[key], gameGiftWait{value: code, at: now})
+	return "", false
+}
@@ -0,0 +1,67 @@
+package zrrk
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func gameGiftMessages(uid, giftID, num int, tid string) (SendGift, LiveIOnteractiveGame) {
+	var gift SendGift
+	gift.Data.UID = uid
+	gift.Data.GiftID = giftID
+	gift.Data.Num = num
+	gift.Data.Price = 100
+	gift.Data.CoinType = "gold"
+	gift.Data.Tid = tid
+	var game LiveIOnteractiveGame
+	game.Data.UID = uid
+	game.Data.GiftID = giftID
+	game.Data.GiftNum = num
+	game.Data.Price = 100
+	game.Data.Paid = true
+	return gift, game
+}
+
+func TestGameGiftLinkOrder(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr
+
+	gift, game := gameGiftMessages(1, 2, 1, "first")
+	b.HandleLiveInteractiveGame(game)
+	b.HandleSendGift(gift)
+	action := (<-b.dataChan).(GameActionData)
+	data := (<-b.dataChan).(GiftData)
+	if action.GiftTID != "" || data.GameCode != GAME_CODE_INTERACTIVE {
+		t.Fatal("gift after game should carry the game code: ", action, data)
+	}
+
+	gift, game = gameGiftMessages(1, 2, 1, "second")
+	b.HandleSendGift(gift)
+	b.HandleLiveInteractiveGame(game)
+	data = (<-b.dataChan).(GiftData)
+	action = (<-b.dataChan).(GameActionData)
+	if data.GameCode != "" || action.GiftTID != "second" {
+		t.Fatal("game after gift should name the gift: ", data, action)
+	}
+}
+
+func TestGameGiftLinkWindow(t *testing.T) {
+	l := newGameGiftLinker(time.Second)
+	key := gameGiftKey{uid: 1, giftID: 2, count: 1}
+	now := time.Now()
+	l.OnGame(key, "game", now)
+	if _, ok := l.OnGift(key, "tid", now.Add(time.Second*2)); ok {
+		t.Fatal("expired game gift should not be linked")
+	}
+	if _, ok := l.OnGame(key, "game", now.Add(time.Second*4)); ok {
+		t.Fatal("expired gift should not be linked")
+	}
+	if _, ok := l.OnGift(gameGiftKey{uid: 1, giftID: 2, count: 2}, "tid", now.Add(time.Second*4)); ok {
+		t.Fatal("gift with a different count should not be linked")
+	}
+	if code, ok := l.OnGift(key, "tid", now.Add(time.Second*4)); !ok || code != "game" {
+		t.Fatal("gift within the window should be linked")
+	}
+}
@@ -0,0 +1,184 @@
+package zrrk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type GuardLevel int
+
+const (
+	GUARD_LEVEL_NONE     GuardLevel = 0
+	GUARD_LEVEL_GOVERNOR GuardLevel = 1
+	GUARD_LEVEL_ADMIRAL  GuardLevel = 2
+	GUARD_LEVEL_CAPTAIN  GuardLevel = 3
+)
+
+func (l GuardLevel) String() string {
+	switch l {
+	case GUARD_LEVEL_GOVERNOR:
+		return "总督"
+	case GUARD_LEVEL_ADMIRAL:
+		return "提督"
+	case GUARD_LEVEL_CAPTAIN:
+		return "舰长"
+	}
+	return ""
+}
+
+// GiftID 返回大航海对应的礼物 ID，与 GUARD_BUY 中的 gift_id 一致。
+func (l GuardLevel) GiftID() int {
+	if l == GUARD_LEVEL_NONE {
+		return 0
+	}
+	return 10000 + int(l)
+}
+
+// Higher 判断是否比另一个等级更高，总督的数值最小。
+func (l GuardLevel) Higher(other GuardLevel) bool {
+	if l == GUARD_LEVEL_NONE {
+		return false
+	}
+	return other == GUARD_LEVEL_NONE || l < other
+}
+
+const (
+	GUARD_UNIT_MONTH = "month"
+	GUARD_UNIT_DAY   = "day"
+)
+
+const (
+	GUARD_OP_UNKNOWN    = 0
+	GUARD_OP_NEW        = 1
+	GUARD_OP_RENEW      = 2
+	GUARD_OP_AUTO_RENEW = 3
+)
+
+func parseGuardUnit(unit string) string {
+	switch unit {
+	case "天":
+		return GUARD_UNIT_DAY
+	}
+	return GUARD_UNIT_MONTH
+}
+
+// guardWait 为 GUARD_BUY 等待对应 USER_TOAST_MSG 的时间，
+// 超时仍未等到时以 GUARD_BUY 的信息发出事件。
+const guardWait = time.Second * 3
+
+// guardSeenTTL 为已发出事件的去重记录保留时间。
+const guardSeenTTL = time.Minute * 10
+
+// guardMerger 合并同一次购买产生的 GUARD_BUY 与 USER_TOAST_MSG。
+// USER_TOAST_MSG 带有 payflow_id、开通类型和单位，优先使用；
+// GUARD_BUY 没有 payflow_id，通过用户、等级和开始时间与其对应。
+// Flush 之后机器人已经退出，之后到达的消息会被忽略。
+type guardMerger struct {
+	lock    sync.Mutex
+	wait    time.Duration
+	pending map[string]*guardPending
+	seen    map[string]time.Time
+	flushed bool
+}
+
+type guardPending struct {
+	event GuardEvent
+	timer *time.Timer
+}
+
+func newGuardMerger(wait time.Duration) *guardMerger {
+	return &guardMerger{
+		wait:    wait,
+		pending: map[string]*guardPending{},
+		seen:    map[string]time.Time{},
+	}
+}
+
+func guardKey(e GuardEvent) string {
+	return fmt.Sprintf("%d-%d-%d", e.User.UID, e.Level, e.StartTime.Unix())
+}
+
+func (m *guardMerger) prune(now time.Time) {
+	for key, t := range m.seen {
+		if now.Sub(t) > guardSeenTTL {
+			delete(m.seen, key)
+		}
+	}
+}
+
+func (m *guardMerger) OnGuardBuy(e GuardEvent, emit func(GuardEvent)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.flushed {
+		return
+	}
+	m.prune(time.Now())
+	key := guardKey(e)
+	if _, ok := m.seen[key]; ok {
+		return
+	}
+	if _, ok := m.pending[key]; ok {
+		return
+	}
+	pending := &guardPending{event: e}
+	pending.timer = time.AfterFunc(m.wait, func() {
+		m.lock.Lock()
+		if m.pending[key] != pending {
+			m.lock.Unlock()
+			return
+		}
+		delete(m.pending, key)
+		m.seen[key] = time.Now()
+		m.lock.Unlock()
+		emit(e)
+	})
+	m.pending[key] = pending
+}
+
+// Flush 停止等待，立即以 GUARD_BUY 的信息发出所有还没等到 USER_TOAST_MSG 的购买，
+// 之后不再接收新的消息。
+func (m *guardMerger) Flush(emit func(GuardEvent)) {
+	m.lock.Lock()
+	pending := m.pending
+	m.pending = map[string]*guardPending{}
+	m.flushed = true
+	now := time.Now()
+	for key, p := range pending {
+		p.timer.Stop()
+		m.seen[key] = now
+	}
+	m.lock.Unlock()
+	for _, p := range pending {
+		emit(p.event)
+	}
+}
+
+func (m *guardMerger) OnToast(e GuardEvent, emit func(GuardEvent)) {
+	m.lock.Lock()
+	if m.flushed {
+		m.lock.Unlock()
+		return
+	}
+	now := time.Now()
+	m.prune(now)
+	if e.PayflowID != "" {
+		if _, ok := m.seen[e.PayflowID]; ok {
+			m.lock.Unlock()
+			return
+		}
+		m.seen[e.PayflowID] = now
+	}
+	key := guardKey(e)
+	if pending, ok := m.pending[key]; ok {
+		pending.timer.Stop()
+		delete(m.pending, key)
+	} else if _, ok := m.seen[key]; ok {
+		// GUARD_BUY 已经超时发出
+		m.lock.Unlock()
+		return
+	}
+	m.seen[key] = now
+	m.lock.Unlock()
+	emit(e)
+}
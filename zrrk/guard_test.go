@@ -0,0 +1,68 @@
+package zrrk
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGuardMerger(t *testing.T) {
+	m := newGuardMerger(time.Millisecond * 20)
+	var lock sync.Mutex
+	var events []GuardEvent
+	emit := func(e GuardEvent) {
+		lock.Lock()
+		events = append(events, e)
+		lock.Unlock()
+	}
+	start := time.Unix(1661460000, 0)
+	buy := GuardEvent{User: User{UID: 1}, Level: GUARD_LEVEL_CAPTAIN, StartTime: start}
+	toast := buy
+	toast.PayflowID = "payflow"
+	toast.OpType = GUARD_OP_NEW
+	toast.IsFirst = true
+
+	m.OnGuardBuy(buy, emit)
+	m.OnToast(toast, emit)
+	m.OnToast(toast, emit)
+	time.Sleep(time.Millisecond * 50)
+	lock.Lock()
+	if len(events) != 1 || !events[0].IsFirst {
+		t.Error("GUARD_BUY and USER_TOAST_MSG should be merged: ", events)
+	}
+	lock.Unlock()
+
+	other := GuardEvent{User: User{UID: 2}, Level: GUARD_LEVEL_ADMIRAL, StartTime: start}
+	m.OnGuardBuy(other, emit)
+	time.Sleep(time.Millisecond * 50)
+	m.OnToast(other, emit)
+	lock.Lock()
+	defer lock.Unlock()
+	if len(events) != 2 || events[1].User.UID != 2 || events[1].OpType != GUARD_OP_UNKNOWN || events[1].IsFirst {
+		t.Error("GUARD_BUY without toast should be emitted once: ", events)
+	}
+}
+
+func TestGuardMergerFlush(t *testing.T) {
+	m := newGuardMerger(time.Millisecond * 20)
+	var events []GuardEvent
+	var lock sync.Mutex
+	emit := func(e GuardEvent) {
+		lock.Lock()
+		events = append(events, e)
+		lock.Unlock()
+	}
+	buy := GuardEvent{User: User{UID: 1}, Level: GUARD_LEVEL_CAPTAIN, StartTime: time.Unix(1661460000, 0)}
+	m.OnGuardBuy(buy, emit)
+	m.Flush(emit)
+	time.Sleep(time.Millisecond * 50)
+	m.OnToast(buy, emit)
+	// Flush 之后的消息不会再启动计时器
+	m.OnGuardBuy(GuardEvent{User: User{UID: 2}, Level: GUARD_LEVEL_CAPTAIN}, emit)
+	time.Sleep(time.Millisecond * 50)
+	lock.Lock()
+	defer lock.Unlock()
+	if len(events) != 1 || events[0].User.UID != 1 {
+		t.Fatal("Flush should emit pending purchases exactly once: ", events)
+	}
+}
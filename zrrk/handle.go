@@ -1,15 +1,19 @@
 package zrrk
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"time"
 )
 
 func (b *Bot) HandleInteractWord(msg InteractWord) {
 	level := msg.Data.FansMedal.MedalLevel
 	medalTitle := msg.Data.FansMedal.MedalName
 	md := Medal{
-		Level: level,
-		Title: medalTitle,
+		Level:  level,
+		Title:  medalTitle,
+		RoomID: msg.Data.FansMedal.AnchorRoomid,
 	}
 	ud := User{
 		Name:  msg.Data.Uname,
@@ -21,46 +25,76 @@ func (b *Bot) HandleInteractWord(msg InteractWord) {
 		b.INFO(fmt.Sprintf("%s：进入了直播间", ud.String()))
 	case INTERACT_FOLLOW:
 		b.INFO(fmt.Sprintf("%s：关注了主播", ud.String()))
+	case INTERACT_SHARE:
+		b.INFO(fmt.Sprintf("%s：分享了直播间", ud.String()))
+	case INTERACT_SPECIAL_FOLLOW:
+		b.INFO(fmt.Sprintf("%s：特别关注了主播", ud.String()))
+	case INTERACT_MUTUAL_FOLLOW:
+		b.INFO(fmt.Sprintf("%s：与主播互粉了", ud.String()))
 	default:
-		b.INFO(fmt.Sprintf("%s", ud.String()))
+		b.INFO(fmt.Sprintf("%s：未知的互动类型 %d", ud.String(), msg.Data.MsgType))
 	}
 	b.dataChan <- InteractData{
-		User: User{
-			Name:  msg.Data.Uname,
-			UID:   msg.Data.UID,
-			Medal: md,
-		},
-		Type: msg.Data.MsgType,
+		RoomID:     b.RoomID,
+		User:       ud,
+		Type:       msg.Data.MsgType,
+		Identities: msg.Data.Identities,
+		Grade:      msg.Data.Contribution.Grade,
+		SpreadInfo: msg.Data.SpreadInfo,
+		Timestamp:  unixOrNow(int64(msg.Data.Timestamp)),
+		SessionID:  b.session.ID(),
 	}
 }
 
 func (b *Bot) HandleUserToastMsg(msg UserToastMsg) {
-	ud := User{
-		Name: msg.Data.Username,
-		UID:  msg.Data.UID,
-	}
-	b.GIFT(fmt.Sprintf("%s：%s！舰长等级Lv.%d, [%s] 价值: %dRMB", ud.String(),
-		msg.Data.ToastMsg, msg.Data.GuardLevel, msg.Data.RoleName, msg.Data.Num*msg.Data.Price/1000))
-	gm := GiftData{
-		User:   ud,
+	b.guards.OnToast(GuardEvent{
 		RoomID: b.RoomID,
-		Gift: Gift{
-			ID:       msg.Data.EffectID,
-			Name:     msg.Data.RoleName,
-			Count:    msg.Data.Num,
-			Price:    msg.Data.Price,
-			Currency: "GOLD",
+		User: User{
+			Name: msg.Data.Username,
+			UID:  msg.Data.UID,
 		},
-	}
-	b.dataChan <- gm
+		Level:       GuardLevel(msg.Data.GuardLevel),
+		Unit:        parseGuardUnit(msg.Data.Unit),
+		Num:         msg.Data.Num,
+		Price:       Gold(msg.Data.Price),
+		OpType:      msg.Data.OpType,
+		IsFirst:     msg.Data.OpType == GUARD_OP_NEW,
+		IsAutoRenew: msg.Data.OpType == GUARD_OP_AUTO_RENEW,
+		PayflowID:   msg.Data.PayflowID,
+		StartTime:   time.Unix(int64(msg.Data.StartTime), 0),
+		EndTime:     time.Unix(int64(msg.Data.EndTime), 0),
+		SessionID:   b.session.ID(),
+	}, b.emitGuard)
 }
 
 func (b *Bot) HandleGuardBuy(msg GuardBuy) {
-	ud := User{
-		Name: msg.Data.Username,
-		UID:  msg.Data.UID,
+	b.guards.OnGuardBuy(GuardEvent{
+		RoomID: b.RoomID,
+		User: User{
+			Name: msg.Data.Username,
+			UID:  msg.Data.UID,
+		},
+		Level:     GuardLevel(msg.Data.GuardLevel),
+		Unit:      GUARD_UNIT_MONTH,
+		Num:       msg.Data.Num,
+		Price:     Gold(msg.Data.Price),
+		OpType:    GUARD_OP_UNKNOWN,
+		StartTime: time.Unix(int64(msg.Data.StartTime), 0),
+		EndTime:   time.Unix(int64(msg.Data.EndTime), 0),
+		SessionID: b.session.ID(),
+	}, b.emitGuard)
+}
+
+func (b *Bot) emitGuard(e GuardEvent) {
+	action := "购买"
+	switch e.OpType {
+	case GUARD_OP_NEW:
+		action = "开通"
+	case GUARD_OP_RENEW, GUARD_OP_AUTO_RENEW:
+		action = "续费"
 	}
-	b.HIGHLIGHT(fmt.Sprintf("%s：上舰了！舰长等级Lv.%d, [%s] 价值: %dRMB", ud.String(), msg.Data.GuardLevel, msg.Data.GiftName, msg.Data.Num*msg.Data.Price/1000))
+	b.HIGHLIGHT(fmt.Sprintf("%s：%s了%s！数量: %d, 价值: %s", e.User.String(), action, e.Level, e.Num, e.Price.Mul(e.Num)))
+	b.dataChan <- e
 }
 
 func (b *Bot) HandleSendGift(msg SendGift) {
@@ -73,32 +107,70 @@ func (b *Bot) HandleSendGift(msg SendGift) {
 		UID:   msg.Data.UID,
 		Medal: md,
 	}
-	if msg.Data.CoinType == "silver" && (msg.Data.Price > 0) {
-		b.GIFT(fmt.Sprintf("%s：%s了 %d 个 %s, [SILVER] 价值: %d", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, msg.Data.Num*msg.Data.Price))
-	} else if (msg.Data.CoinType == "gold") && (msg.Data.Price > 0) {
-		b.HIGHLIGHT(fmt.Sprintf("%s：%s了 %d 个 %s, [ GOLD ] 价值: %.1fRMB", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, float64(msg.Data.Num*msg.Data.Price)/1000))
+	price := Money{Amount: msg.Data.Price, Currency: parseCoinType(msg.Data.CoinType)}
+	total := price.Mul(msg.Data.Num)
+	if price.Currency == CURRENCY_SILVER && (msg.Data.Price > 0) {
+		b.GIFT(fmt.Sprintf("%s：%s了 %d 个 %s, [SILVER] 价值: %s", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, total))
+	} else if price.Currency == CURRENCY_GOLD && (msg.Data.Price > 0) {
+		b.HIGHLIGHT(fmt.Sprintf("%s：%s了 %d 个 %s, [ GOLD ] 价值: %s", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, total))
 	} else {
-		b.DEBUG(fmt.Sprintf("%s：%s了 %d 个 %s, [OTHERS] 价值: %d", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, msg.Data.Num*msg.Data.Price))
+		b.DEBUG(fmt.Sprintf("%s：%s了 %d 个 %s, [OTHERS] 价值: %s", ud.String(), msg.Data.Action, msg.Data.Num, msg.Data.GiftName, total))
+	}
+	gift := Gift{
+		ID:        msg.Data.GiftID,
+		Name:      msg.Data.GiftName,
+		Count:     msg.Data.Num,
+		Price:     price,
+		PaidPrice: price,
 	}
-	price := msg.Data.Price
-	currency := "SILVER"
-	if msg.Data.CoinType == "gold" {
-		currency = "GOLD"
-		price = msg.Data.Price
+	if blind := msg.Data.BlindGift; blind != nil {
+		gift.PaidPrice = Money{Amount: blind.OriginalGiftPrice, Currency: price.Currency}
+		gift.BlindBoxID = blind.OriginalGiftID
+		gift.BlindBoxName = blind.OriginalGiftName
+		b.GIFT(fmt.Sprintf("%s：%s%s了 %d 个 %s, 盈亏: %s", ud.String(), blind.OriginalGiftName, blind.GiftAction, gift.Count, gift.Name, gift.Profit()))
 	}
 
 	gm := GiftData{
-		RoomID: b.RoomID,
-		User:   ud,
-		Gift: Gift{
-			ID:       msg.Data.GiftID,
-			Name:     msg.Data.GiftName,
-			Count:    msg.Data.Num,
-			Price:    price,
-			Currency: currency,
-		},
+		RoomID:       b.RoomID,
+		User:         ud,
+		Gift:         gift,
+		TID:          msg.Data.Tid,
+		BatchComboID: msg.Data.BatchComboID,
+		GameCode:     b.game.Code(),
+		SessionID:    b.session.ID(),
+	}
+	if price.IsPaid() {
+		key := gameGiftKey{uid: ud.UID, giftID: gift.ID, count: gift.Count}
+		if code, ok := b.gameGifts.OnGift(key, gm.TID, time.Now()); ok {
+			gm.GameCode = code
+		}
+	}
+	if gm.BatchComboID == "" || b.ComboMode != COMBO_MODE_SUMMARY {
+		b.dataChan <- gm
+	}
+	if gm.BatchComboID != "" && b.ComboMode != COMBO_MODE_PACKET {
+		b.combos.Add(gm, b.emitCombo)
 	}
-	b.dataChan <- gm
+}
+
+func (b *Bot) HandleComboSend(msg ComboSend) {
+	b.DEBUG(fmt.Sprintf("%s(UID: %d)：连击 %s x %d", msg.Data.Uname, msg.Data.UID, msg.Data.GiftName, msg.Data.BatchComboNum))
+	if msg.Data.BatchComboID == "" || b.ComboMode == COMBO_MODE_PACKET {
+		return
+	}
+	b.combos.Update(msg, b.emitCombo)
+}
+
+// emitCombo 发出连击汇总。COMBO_MODE_SUMMARY 下连击的 SEND_GIFT 不会单独发出，
+// 此时 Summary 为 true，统计收入的插件应当记录这个汇总。
+func (b *Bot) emitCombo(c ComboData) {
+	c.RoomID = b.RoomID
+	c.Summary = b.ComboMode == COMBO_MODE_SUMMARY
+	if c.SessionID == "" {
+		c.SessionID = b.session.ID()
+	}
+	b.GIFT(fmt.Sprintf("%s：连击结束，共 %d 个 %s, 价值: %s", c.User.String(), c.Gift.Count, c.Gift.Name, c.Total))
+	b.dataChan <- c
 }
 
 func (b *Bot) HandleDanmuMsg(msg DanmuMsg) {
@@ -108,27 +180,40 @@ func (b *Bot) HandleDanmuMsg(msg DanmuMsg) {
 	uname := userInfo[1].(string)
 	medal := msg.Info[3].([]interface{})
 	medalData := Medal{}
-	user := User{
-		Name:  uname,
-		UID:   uid,
-		Medal: medalData,
-	}
 	if len(medal) != 0 {
 		lv := int(medal[0].(float64))
 		modalTitle := medal[1].(string)
 		// up := modal[2].(string)
 		medalData.Level = lv
 		medalData.Title = modalTitle
+		if len(medal) > 3 {
+			roomID, _ := medal[3].(float64)
+			medalData.RoomID = int(roomID)
+		}
 	}
 	ud := User{
 		Name:  uname,
 		UID:   uid,
 		Medal: medalData,
 	}
+	isAdmin := false
+	if len(userInfo) > 2 {
+		admin, _ := userInfo[2].(float64)
+		isAdmin = admin == 1
+	}
+	guardLevel := GUARD_LEVEL_NONE
+	if len(msg.Info) > 7 {
+		level, _ := msg.Info[7].(float64)
+		guardLevel = GuardLevel(level)
+	}
 	b.INFO(fmt.Sprintf("%s: %s", ud.String(), text))
 	b.dataChan <- DanmakuData{
-		User: user,
-		Text: text,
+		RoomID:     b.RoomID,
+		User:       ud,
+		Text:       text,
+		GuardLevel: guardLevel,
+		IsAdmin:    isAdmin,
+		SessionID:  b.session.ID(),
 	}
 }
 
@@ -142,20 +227,704 @@ func (b *Bot) handleSC(msg SuperChatMessage) {
 		UID:   msg.Data.UID,
 		Medal: md,
 	}
-	b.dataChan <- SCData{
-		User: ud,
-		Text: msg.Data.Message,
-	}
+	sessionID := b.session.ID()
+	sc := b.scBoard.Add(SCData{
+		RoomID:       b.RoomID,
+		ID:           msg.Data.ID,
+		User:         ud,
+		Text:         msg.Data.Message,
+		MessageTrans: msg.Data.MessageTrans,
+		Price:        Yuan(msg.Data.Price),
+		StartTime:    time.Unix(int64(msg.Data.StartTime), 0),
+		EndTime:      time.Unix(int64(msg.Data.EndTime), 0),
+		SessionID:    sessionID,
+	})
+	b.dataChan <- sc
 	b.HIGHLIGHT(fmt.Sprintf("%s：<%d RMB> SC ** %s **", ud.String(), msg.Data.Price, msg.Data.Message))
 	b.dataChan <- GiftData{
 		RoomID: b.RoomID,
 		User:   ud,
 		Gift: Gift{
-			ID:       msg.Data.Gift.GiftID,
-			Name:     msg.Data.Gift.GiftName,
-			Count:    msg.Data.Gift.Num,
-			Price:    msg.Data.Price * 1000,
-			Currency: "GOLD",
+			ID:        msg.Data.Gift.GiftID,
+			Name:      msg.Data.Gift.GiftName,
+			Count:     msg.Data.Gift.Num,
+			Price:     sc.Price,
+			PaidPrice: sc.Price,
 		},
+		SessionID: sessionID,
+	}
+}
+
+func (b *Bot) HandleLive(msg Live) {
+	startTime := time.Now()
+	if msg.LiveTime > 0 {
+		startTime = time.Unix(int64(msg.LiveTime), 0)
+	}
+	session, started := b.session.Start(Session{
+		RoomID:        b.RoomID,
+		LiveKey:       msg.LiveKey,
+		SubSessionKey: msg.SubSessionKey,
+		StartTime:     startTime,
+	})
+	if !started {
+		return
+	}
+	b.INFO(fmt.Sprintf("现在已开始直播，场次: %s", session.ID))
+	b.dataChan <- LiveStatusData{
+		RoomID:  b.RoomID,
+		Status:  LIVE_STATUS_LIVE,
+		Session: session,
+	}
+}
+
+func (b *Bot) HandlePreparing(msg Preparing) {
+	session, ended := b.session.End(time.Now())
+	if !ended {
+		return
+	}
+	status := LIVE_STATUS_PREPARING
+	if msg.Round == 1 {
+		status = LIVE_STATUS_ROUND
+	}
+	b.INFO(fmt.Sprintf("直播间正准备中，场次 %s 已结束，时长: %s", session.ID, session.Duration().Truncate(time.Second)))
+	b.endGame(session.ID)
+	b.dataChan <- LiveStatusData{
+		RoomID:  b.RoomID,
+		Status:  status,
+		Session: session,
+	}
+}
+
+func (b *Bot) HandleCutOff(msg CutOff) {
+	session, ended := b.session.End(time.Now())
+	b.WARNING(fmt.Sprintf("直播被切断: %s", msg.Msg))
+	if !ended {
+		return
+	}
+	b.endGame(session.ID)
+	b.dataChan <- LiveStatusData{
+		RoomID:  b.RoomID,
+		Status:  LIVE_STATUS_CUT_OFF,
+		Msg:     msg.Msg,
+		Session: session,
+	}
+}
+
+func (b *Bot) HandleRoomChange(msg RoomChange) {
+	b.INFO(fmt.Sprintf("修改了房间信息: %s [%s - %s]", msg.Data.Title, msg.Data.ParentAreaName, msg.Data.AreaName))
+	b.dataChan <- RoomChangeData{
+		RoomID:         b.RoomID,
+		Title:          msg.Data.Title,
+		AreaID:         msg.Data.AreaID,
+		AreaName:       msg.Data.AreaName,
+		ParentAreaID:   msg.Data.ParentAreaID,
+		ParentAreaName: msg.Data.ParentAreaName,
+		SessionID:      b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleRoomBlockMsg(msg RoomBlockMsg) {
+	ud := User{
+		Name: msg.Data.Uname,
+		UID:  msg.Data.UID,
 	}
+	operator := "房管"
+	if msg.Data.Operator == MODERATION_OPERATOR_ANCHOR {
+		operator = "主播"
+	}
+	b.INFO(fmt.Sprintf("%s：被%s封禁", ud.String(), operator))
+	b.dataChan <- ModerationData{
+		RoomID:    b.RoomID,
+		Type:      MODERATION_BLOCK,
+		Operator:  msg.Data.Operator,
+		User:      ud,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleRoomAdmins(msg RoomAdmins) {
+	b.admins.Set(msg.UIDs)
+	b.INFO(fmt.Sprintf("房管列表已更新，共 %d 人", len(msg.UIDs)))
+	b.dataChan <- AdminListData{
+		RoomID: b.RoomID,
+		UIDs:   b.admins.List(),
+	}
+}
+
+func (b *Bot) HandleRoomAdminEntrance(msg RoomAdminEntrance) {
+	b.admins.Add(msg.UID)
+	b.INFO(fmt.Sprintf("UID %d 被设为房管", msg.UID))
+	b.dataChan <- ModerationData{
+		RoomID:    b.RoomID,
+		Type:      MODERATION_ADMIN_ADD,
+		Operator:  MODERATION_OPERATOR_ANCHOR,
+		User:      User{UID: msg.UID},
+		Level:     msg.Level,
+		Msg:       msg.Msg,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleRoomAdminRevoke(msg RoomAdminRevoke) {
+	b.admins.Remove(msg.UID)
+	b.INFO(fmt.Sprintf("UID %d 被撤销房管", msg.UID))
+	b.dataChan <- ModerationData{
+		RoomID:    b.RoomID,
+		Type:      MODERATION_ADMIN_REVOKE,
+		Operator:  MODERATION_OPERATOR_ANCHOR,
+		User:      User{UID: msg.UID},
+		Msg:       msg.Msg,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleRoomSilentOn(msg RoomSilentOn) {
+	md := ModerationData{
+		RoomID:    b.RoomID,
+		Type:      MODERATION_SILENT_ON,
+		Operator:  MODERATION_OPERATOR_ANCHOR,
+		Scope:     msg.Data.Type,
+		Level:     msg.Data.Level,
+		SessionID: b.session.ID(),
+	}
+	// second 为 -1 时表示禁言到本场直播结束
+	if msg.Data.Second > 0 {
+		md.EndTime = time.Unix(msg.Data.Second, 0)
+		md.Duration = time.Until(md.EndTime).Truncate(time.Second)
+	}
+	b.INFO(fmt.Sprintf("直播间开启了禁言: [%s] Lv.%d, 持续: %s", md.Scope, md.Level, md.Duration))
+	b.dataChan <- md
+}
+
+func (b *Bot) HandleRoomSilentOff(msg RoomSilentOff) {
+	b.INFO("直播间关闭了禁言")
+	b.dataChan <- ModerationData{
+		RoomID:    b.RoomID,
+		Type:      MODERATION_SILENT_OFF,
+		Operator:  MODERATION_OPERATOR_ANCHOR,
+		SessionID: b.session.ID(),
+	}
+}
+
+// HandleSCDelete 总是以服务器给出的 ID 发出删除事件，即使 SC 不在醒目留言板上，
+// 例如重连前或机器人启动前收到的 SC；Removed 为实际从醒目留言板上移除的 ID。
+func (b *Bot) HandleSCDelete(msg SuperChatMessageDelete) {
+	removed := b.scBoard.Delete(msg.Data.IDs...)
+	b.INFO(fmt.Sprintf("SC 被删除: %v", msg.Data.IDs))
+	ids := make([]int, len(removed))
+	for i, sc := range removed {
+		ids[i] = sc.ID
+	}
+	b.dataChan <- SCDeleteData{
+		RoomID:  b.RoomID,
+		IDs:     msg.Data.IDs,
+		Removed: ids,
+	}
+}
+
+func (b *Bot) HandleSCJPN(msg SuperChatMessageJPN) {
+	id, err := strconv.Atoi(msg.Data.ID)
+	if err != nil {
+		b.ERROR("解析日语 SC 失败: ", err)
+		return
+	}
+	if _, ok := b.scBoard.Translate(id, msg.Data.MessageJpn); !ok {
+		// 翻译先于 SC 到达时，日语 SC 本身也带有完整信息
+		uid, _ := strconv.Atoi(msg.Data.UID)
+		b.scBoard.Add(SCData{
+			RoomID: b.RoomID,
+			ID:     id,
+			User: User{
+				Name: msg.Data.UserInfo.Uname,
+				UID:  uid,
+				Medal: Medal{
+					Level: msg.Data.MedalInfo.MedalLevel,
+					Title: msg.Data.MedalInfo.MedalName,
+				},
+			},
+			Text:         msg.Data.Message,
+			MessageTrans: msg.Data.MessageJpn,
+			Price:        Yuan(msg.Data.Price),
+			StartTime:    time.Unix(int64(msg.Data.StartTime), 0),
+			EndTime:      time.Unix(int64(msg.Data.EndTime), 0),
+			SessionID:    b.session.ID(),
+		})
+	}
+	b.DEBUG(fmt.Sprintf("日本语超级弹幕: %s", msg.Data.MessageJpn))
+	b.dataChan <- SCTranslationData{
+		RoomID:       b.RoomID,
+		ID:           id,
+		MessageTrans: msg.Data.MessageJpn,
+	}
+}
+
+func (b *Bot) HandleOnlineRankV2(msg OnlineRankV2) {
+	list := make([]RankUser, 0, len(msg.Data.List))
+	for _, item := range msg.Data.List {
+		score, _ := strconv.Atoi(item.Score)
+		list = append(list, RankUser{
+			User: User{
+				Name: item.Uname,
+				UID:  item.UID,
+			},
+			Rank:       item.Rank,
+			Score:      score,
+			GuardLevel: GuardLevel(item.GuardLevel),
+		})
+	}
+	changes := b.rank.Update(list)
+	for _, change := range changes {
+		change.RoomID = b.RoomID
+		switch change.Type {
+		case RANK_CHANGE_ENTER:
+			b.DEBUG(fmt.Sprintf("%s：进入高能榜第 %d 名", change.User.User.String(), change.User.Rank))
+		case RANK_CHANGE_LEAVE:
+			b.DEBUG(fmt.Sprintf("%s：离开了高能榜", change.User.User.String()))
+		}
+		b.dataChan <- change
+	}
+	b.dataChan <- OnlineRankData{
+		RoomID: b.RoomID,
+		List:   list,
+		Count:  b.rank.Count(),
+	}
+}
+
+func (b *Bot) HandleOnlineRankCount(msg OnlineRankCount) {
+	b.rank.SetCount(msg.Data.Count)
+	b.DEBUG("高能榜数量更新: ", msg.Data.Count)
+}
+
+func (b *Bot) HandleOnlineRankTop3(msg OnlineRankTop3) {
+	for _, item := range msg.Data.List {
+		b.DEBUG(fmt.Sprintf("高能榜发生变动: %s", StripMarkup(item.Msg)))
+	}
+}
+
+func (b *Bot) HandleWatchedChange(msg WatchedChange) {
+	b.INFO("观看人数有变动: ", msg.Data.TextLarge)
+	b.dataChan <- WatchedData{
+		RoomID:    b.RoomID,
+		Num:       msg.Data.Num,
+		Text:      msg.Data.TextLarge,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleLikeInfoV3Update(msg LikeInfoV3Update) {
+	b.DEBUG("点赞数有变动: ", msg.Data.ClickCount)
+	b.dataChan <- LikeData{
+		RoomID:     b.RoomID,
+		ClickCount: msg.Data.ClickCount,
+		SessionID:  b.session.ID(),
+	}
+}
+
+func (b *Bot) HandlePopularity(value int32) {
+	b.INFO("当前直播间热度: ", value)
+	b.dataChan <- PopularityData{
+		RoomID:    b.RoomID,
+		Value:     int(value),
+		SessionID: b.session.ID(),
+	}
+}
+
+func toGoods(item GoodsItem) Goods {
+	return Goods{
+		ID:       item.GoodsID,
+		Name:     item.GoodsName,
+		Price:    item.GoodsPrice,
+		MaxPrice: item.GoodsMaxPrice,
+		Icon:     item.GoodsIcon,
+	}
+}
+
+func (b *Bot) HandleShoppingCartShow(msg ShoppingCartShow) {
+	show := msg.Data.Status == 1
+	b.INFO("购物车展示状态变动: ", show)
+	b.dataChan <- ShoppingCartData{
+		RoomID:    b.RoomID,
+		Show:      show,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleSelectedGoodsInfo(msg SelectedGoodsInfo) {
+	goods := make([]Goods, 0, len(msg.Data.Item))
+	for _, item := range msg.Data.Item {
+		goods = append(goods, toGoods(item))
+	}
+	b.INFO(fmt.Sprintf("上架了 %d 件商品: %s", len(goods), msg.Data.Title))
+	b.dataChan <- GoodsData{
+		RoomID:     b.RoomID,
+		Type:       GOODS_LISTED,
+		ChangeType: msg.Data.ChangeType,
+		Title:      msg.Data.Title,
+		Goods:      goods,
+		SessionID:  b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleShoppingExplainCard(msg ShoppingExplainCard) {
+	b.INFO(fmt.Sprintf("正在讲解商品: %s [%s]", msg.Data.GoodsName, msg.Data.GoodsPrice))
+	b.dataChan <- GoodsData{
+		RoomID:    b.RoomID,
+		Type:      GOODS_EXPLAINED,
+		Goods:     []Goods{toGoods(msg.Data.GoodsItem)},
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleShoppingBubblesStyle(msg ShoppingBubblesStyle) {
+	bubbles := make([]ShoppingBubble, 0, len(msg.Data.BubblesList))
+	for _, item := range msg.Data.BubblesList {
+		bubbles = append(bubbles, ShoppingBubble{
+			Tag:      item.Tag,
+			Name:     item.Name,
+			GoodsIDs: item.GoodsList,
+		})
+	}
+	b.DEBUG("购物气泡样式更新, 商品数: ", msg.Data.GoodsCount)
+	b.dataChan <- ShoppingBubblesData{
+		RoomID:     b.RoomID,
+		GoodsCount: msg.Data.GoodsCount,
+		Bubbles:    bubbles,
+		SessionID:  b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleHotBuyNum(msg HotBuyNum) {
+	b.DEBUG(fmt.Sprintf("商品 %s 热抢人数: %d", msg.Data.GoodsID, msg.Data.Num))
+	b.dataChan <- HotBuyData{
+		RoomID:    b.RoomID,
+		GoodsID:   msg.Data.GoodsID,
+		Num:       msg.Data.Num,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleGotoBuyFlow(msg GotoBuyFlow) {
+	b.DEBUG(msg.Data.Text)
+	b.dataChan <- GotoBuyData{
+		RoomID:    b.RoomID,
+		Text:      msg.Data.Text,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) startCoStream(cs CoStream, text string) {
+	if !b.session.StartCoStream(cs) {
+		return
+	}
+	b.INFO(fmt.Sprintf("开始连麦 [%s]: %s(UID: %d)", cs.Type, cs.PartnerName, cs.PartnerUID))
+	b.dataChan <- CoStreamData{
+		RoomID:    b.RoomID,
+		Action:    COSTREAM_START,
+		CoStream:  cs,
+		Text:      text,
+		SessionID: b.session.ID(),
+	}
+}
+
+// endCoStream 结束已记录的连麦，同一次结束的多条消息只会发出一次。
+func (b *Bot) endCoStream(typ, channelID string, endTime time.Time, text string) {
+	cs, ok := b.session.EndCoStream(typ, channelID, endTime)
+	if !ok {
+		return
+	}
+	b.INFO(fmt.Sprintf("结束连麦 [%s]: %s", typ, text))
+	b.dataChan <- CoStreamData{
+		RoomID:    b.RoomID,
+		Action:    COSTREAM_END,
+		CoStream:  cs,
+		Text:      text,
+		SessionID: b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleVoiceJoinStatus(msg VoiceJoinStatus) {
+	if msg.Data.Status == 1 {
+		b.startCoStream(CoStream{
+			Type:        COSTREAM_VOICE,
+			ChannelID:   msg.Data.Channel,
+			PartnerUID:  msg.Data.UID,
+			PartnerName: msg.Data.UserName,
+			StartTime:   unixOrNow(msg.Data.StartAt),
+		}, "")
+		return
+	}
+	b.endCoStream(COSTREAM_VOICE, msg.Data.Channel, unixOrNow(msg.Data.CurrentTime), "")
+}
+
+func (b *Bot) HandleVoiceJoinRoomCountInfo(msg VoiceJoinRoomCountInfo) {
+	b.DEBUG("语音连麦申请人数: ", msg.Data.ApplyCount)
+}
+
+func (b *Bot) HandleVoiceJoinList(msg VoiceJoinList) {
+	b.DEBUG("语音连麦列表更新, 申请人数: ", msg.Data.ApplyCount)
+}
+
+func (b *Bot) HandleVideoConnectionJoinStart(msg VideoConnectionJoinStart) {
+	b.startCoStream(CoStream{
+		Type:        COSTREAM_VIDEO,
+		ChannelID:   msg.Data.ChannelID,
+		PartnerUID:  msg.Data.InvitedUID,
+		PartnerName: msg.Data.InvitedUname,
+		StartTime:   unixOrNow(msg.Data.StartAt),
+	}, "")
+}
+
+func (b *Bot) HandleVideoConnectionJoinEnd(msg VideoConnectionJoinEnd) {
+	b.endCoStream(COSTREAM_VIDEO, msg.Data.ChannelID, unixOrNow(msg.Data.CurrentTime), msg.Data.Toast)
+}
+
+func (b *Bot) HandleVideoConnectionMsg(msg VideoConnectionMsg) {
+	b.DEBUG(msg.Data.Toast)
+	b.endCoStream(COSTREAM_VIDEO, msg.Data.ChannelID, unixOrNow(msg.Data.CurrentTime), msg.Data.Toast)
+}
+
+func (b *Bot) HandleLiveOpenPlatformGame(msg LiveOpenPlatformGame) {
+	game := GameData{
+		RoomID:    b.RoomID,
+		GameName:  msg.Data.GameName,
+		GameCode:  msg.Data.GameCode,
+		GameID:    msg.Data.GameID,
+		Time:      unixOrNow(msg.Data.Timestamp),
+		SessionID: b.session.ID(),
+	}
+	switch msg.Data.MsgType {
+	case "game_start":
+		game.Action = GAME_START
+		b.game.Start(game)
+		b.INFO(fmt.Sprintf("开始了互动玩法: %s", game.GameName))
+	case "game_end":
+		game.Action = GAME_END
+		b.game.End()
+		b.INFO(fmt.Sprintf("结束了互动玩法: %s", game.GameName))
+	default:
+		b.DEBUG(fmt.Sprintf("互动玩法 %s: %s", game.GameName, msg.Data.MsgType))
+		return
+	}
+	b.dataChan <- game
+}
+
+// endGame 在场次结束时结束仍在进行的玩法，之后的礼物不再归属于它。
+func (b *Bot) endGame(sessionID string) {
+	game, playing := b.game.End()
+	if !playing {
+		return
+	}
+	game.Action = GAME_END
+	game.Time = time.Now()
+	game.SessionID = sessionID
+	b.INFO(fmt.Sprintf("场次结束，互动玩法 %s 随之结束", game.GameName))
+	b.dataChan <- game
+}
+
+func (b *Bot) HandleLiveInteractiveGame(msg LiveIOnteractiveGame) {
+	ud := User{
+		Name: msg.Data.Uname,
+		UID:  msg.Data.UID,
+		Medal: Medal{
+			Level: msg.Data.FansMedalLevel,
+		},
+	}
+	action := GameActionData{
+		RoomID:     b.RoomID,
+		Type:       msg.Data.Type,
+		User:       ud,
+		GuardLevel: GuardLevel(msg.Data.GuardLevel),
+		Text:       msg.Data.Msg,
+		Paid:       msg.Data.Paid,
+		GameCode:   b.game.Code(),
+		Time:       unixOrNow(int64(msg.Data.Timestamp)),
+		SessionID:  b.session.ID(),
+	}
+	if msg.Data.GiftID != 0 {
+		gift := Gift{
+			ID:        msg.Data.GiftID,
+			Name:      msg.Data.GiftName,
+			Count:     msg.Data.GiftNum,
+			Price:     Silver(msg.Data.Price),
+			PaidPrice: Silver(msg.Data.Price),
+		}
+		if msg.Data.Paid {
+			gift.Price = Gold(msg.Data.Price)
+			gift.PaidPrice = gift.Price
+		}
+		action.Gift = &gift
+		if action.Paid {
+			code := action.GameCode
+			if code == "" {
+				code = GAME_CODE_INTERACTIVE
+			}
+			key := gameGiftKey{uid: ud.UID, giftID: gift.ID, count: gift.Count}
+			action.GiftTID, _ = b.gameGifts.OnGame(key, code, time.Now())
+		}
+		b.DEBUG(fmt.Sprintf("%s：在互动玩法中送出了 %d 个 %s", ud.String(), gift.Count, gift.Name))
+	}
+	b.dataChan <- action
+}
+
+func (b *Bot) HandleEntryEffect(msg EntryEffect) {
+	level := GUARD_LEVEL_NONE
+	if msg.Data.PrivilegeType >= int(GUARD_LEVEL_GOVERNOR) && msg.Data.PrivilegeType <= int(GUARD_LEVEL_CAPTAIN) {
+		level = GuardLevel(msg.Data.PrivilegeType)
+	}
+	enterTime := time.Now()
+	if msg.Data.TriggerTime > 0 {
+		// trigger_time 为纳秒时间戳
+		enterTime = time.Unix(0, msg.Data.TriggerTime)
+	}
+	ud := User{
+		Name: markedText(msg.Data.CopyWriting),
+		UID:  msg.Data.UID,
+	}
+	text := StripMarkup(msg.Data.CopyWriting)
+	b.DEBUG(fmt.Sprintf("入场特效：%s", text))
+	b.dataChan <- EntryEffectData{
+		RoomID:        b.RoomID,
+		User:          ud,
+		GuardLevel:    level,
+		PrivilegeType: msg.Data.PrivilegeType,
+		Text:          text,
+		Time:          enterTime,
+		SessionID:     b.session.ID(),
+	}
+}
+
+func (b *Bot) HandleWidgetWishList(msg WidgetWishList) {
+	wishes := make([]Wish, 0, len(msg.Data.Wish))
+	for _, w := range msg.Data.Wish {
+		wishes = append(wishes, Wish{
+			Type:      w.Type,
+			GiftID:    w.GiftID,
+			GiftName:  w.GiftName,
+			GiftPrice: Gold(w.GiftPrice),
+			Target:    w.TargetNum,
+			Current:   w.CurrentNum,
+		})
+	}
+	b.dataChan <- WishListData{
+		RoomID:    b.RoomID,
+		Name:      msg.Data.WishName,
+		Status:    msg.Data.WishStatus,
+		Wishes:    wishes,
+		SessionID: b.session.ID(),
+	}
+	for _, w := range b.wishes.Update(wishes) {
+		b.mark(Milestone{
+			Type: MILESTONE_WISH_REACHED,
+			Text: fmt.Sprintf("%s %d/%d", w.GiftName, w.Current, w.Target),
+		})
+	}
+}
+
+func (b *Bot) HandleGuardAchievementRoom(msg GuardAchievementRoom) {
+	text := StripMarkup(msg.Data.FirstLineContent + msg.Data.SecondLineContent)
+	b.dataChan <- GuardAchievementData{
+		RoomID:    b.RoomID,
+		Level:     msg.Data.AnchorGuardAchieveLevel,
+		IsFirst:   msg.Data.IsFirst,
+		Text:      text,
+		SessionID: b.session.ID(),
+	}
+	b.mark(Milestone{Type: MILESTONE_GUARD_ACHIEVEMENT, Text: text})
+}
+
+func (b *Bot) HandleRoomSkinMsg(msg RoomSkinMsg) {
+	b.dataChan <- RoomSkinData{
+		RoomID:    b.RoomID,
+		SkinID:    msg.SkinID,
+		Status:    msg.Status,
+		EndTime:   time.Unix(msg.EndTime, 0),
+		SessionID: b.session.ID(),
+	}
+	b.mark(Milestone{
+		Type: MILESTONE_ROOM_SKIN,
+		Text: fmt.Sprintf("皮肤 %d 状态 %d", msg.SkinID, msg.Status),
+	})
+}
+
+func (b *Bot) HandleActivityBannerChange(msg ActivityBannerChange) {
+	banners := make([]ActivityBanner, 0, len(msg.Data.List))
+	for _, banner := range msg.Data.List {
+		banners = append(banners, ActivityBanner{
+			ID:       banner.ID,
+			Title:    banner.ActivityTitle,
+			Position: banner.Position,
+			JumpURL:  banner.JumpURL,
+			Action:   banner.Action,
+			IsClose:  banner.IsClose == 1,
+			Time:     unixOrNow(banner.Timestamp),
+		})
+	}
+	b.dataChan <- ActivityBannerData{
+		RoomID:    b.RoomID,
+		Banners:   banners,
+		SessionID: b.session.ID(),
+	}
+	for _, banner := range banners {
+		b.mark(Milestone{
+			Type: MILESTONE_ACTIVITY_BANNER,
+			Text: fmt.Sprintf("%s: %s", banner.Action, banner.Title),
+			Time: banner.Time,
+		})
+	}
+}
+
+// discoveryBuffer 为等待交给 OnDiscover 的直播间数量上限。
+const discoveryBuffer = 100
+
+type discovery struct {
+	roomID int
+	source string
+}
+
+// discover 将其他直播间交给 OnDiscover，忽略本直播间和无效的房间号。
+// 不会阻塞接收消息的协程，等待中的直播间已满时丢弃。
+func (b *Bot) discover(roomID int, source string) {
+	if b.OnDiscover == nil || roomID <= 0 || roomID == b.RoomID {
+		return
+	}
+	b.DEBUG(fmt.Sprintf("发现直播间 [%s]: %d", source, roomID))
+	select {
+	case b.discoveries <- discovery{roomID: roomID, source: source}:
+	default:
+		b.WARNING(fmt.Sprintf("发现的直播间来不及处理，丢弃: %d", roomID))
+	}
+}
+
+// deliverDiscoveries 依次将发现的直播间交给 OnDiscover，直到 ctx 被取消。
+func (b *Bot) deliverDiscoveries(ctx context.Context) {
+	for {
+		select {
+		case d := <-b.discoveries:
+			b.OnDiscover(d.roomID, d.source)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *Bot) HandleNoticeMsg(msg NoticeMsg) {
+	b.dataChan <- NoticeData{
+		RoomID:     b.RoomID,
+		RealRoomID: msg.RealRoomid,
+		Name:       msg.Name,
+		MsgType:    msg.MsgType,
+		Text:       StripMarkup(msg.MsgCommon),
+		LinkURL:    msg.LinkURL,
+		SessionID:  b.session.ID(),
+	}
+	b.discover(msg.RealRoomid, DISCOVER_NOTICE)
+}
+
+func (b *Bot) HandlePkBattlePre(msg PkBattlePre) {
+	b.discover(msg.Data.RoomID, DISCOVER_PK)
+}
+
+func (b *Bot) HandlePkBattleStartNew(msg PkBattleStartNew) {
+	b.discover(msg.Data.InitInfo.RoomID, DISCOVER_PK)
+	b.discover(msg.Data.MatchInfo.RoomID, DISCOVER_PK)
 }
@@ -11,7 +11,7 @@ type SendGift struct {
 		BatchComboSend    interface{} `json:"batch_combo_send"`
 		BeatID            string      `json:"beatId"`
 		BizSource         string      `json:"biz_source"`
-		BlindGift         interface{} `json:"blind_gift"`
+		BlindGift         *BlindGift  `json:"blind_gift"`
 		BroadcastID       int         `json:"broadcast_id"`
 		CoinType          string      `json:"coin_type"`
 		ComboResourcesID  int         `json:"combo_resources_id"`
@@ -72,6 +72,15 @@ type SendGift struct {
 		Uname             string      `json:"uname"`
 	} `json:"data"`
 }
+type BlindGift struct {
+	BlindGiftConfigID int    `json:"blind_gift_config_id"`
+	From              int    `json:"from"`
+	GiftAction        string `json:"gift_action"`
+	GiftTipPrice      int    `json:"gift_tip_price"`
+	OriginalGiftID    int    `json:"original_gift_id"`
+	OriginalGiftName  string `json:"original_gift_name"`
+	OriginalGiftPrice int    `json:"original_gift_price"`
+}
 type EntryEffect struct {
 	Cmd  string `json:"cmd"`
 	Data struct {
@@ -476,3 +485,363 @@ type LikeInfoV3Update struct {
 		ClickCount int `json:"click_count"`
 	} `json:"data"`
 }
+
+type CutOff struct {
+	Cmd    string `json:"cmd"`
+	Msg    string `json:"msg"`
+	Roomid int    `json:"roomid"`
+}
+
+type RoomAdmins struct {
+	Cmd  string `json:"cmd"`
+	UIDs []int  `json:"uids"`
+}
+
+type RoomAdminRevoke struct {
+	Cmd string `json:"cmd"`
+	Msg string `json:"msg"`
+	UID int    `json:"uid"`
+}
+
+type RoomAdminEntrance struct {
+	Cmd     string `json:"cmd"`
+	Dmscore int    `json:"dmscore"`
+	Level   int    `json:"level"`
+	Msg     string `json:"msg"`
+	UID     int    `json:"uid"`
+}
+
+type RoomSilentOn struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Type   string `json:"type"`
+		Level  int    `json:"level"`
+		Second int64  `json:"second"`
+	} `json:"data"`
+}
+
+type RoomSilentOff struct {
+	Cmd string `json:"cmd"`
+}
+
+type SuperChatMessageDelete struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		IDs []int `json:"ids"`
+	} `json:"data"`
+	Roomid int `json:"roomid"`
+}
+
+type ComboSend struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Action         string `json:"action"`
+		BatchComboID   string `json:"batch_combo_id"`
+		BatchComboNum  int    `json:"batch_combo_num"`
+		ComboID        string `json:"combo_id"`
+		ComboNum       int    `json:"combo_num"`
+		ComboTotalCoin int    `json:"combo_total_coin"`
+		Dmscore        int    `json:"dmscore"`
+		GiftID         int    `json:"gift_id"`
+		GiftName       string `json:"gift_name"`
+		GiftNum        int    `json:"gift_num"`
+		IsShow         int    `json:"is_show"`
+		MedalInfo      struct {
+			AnchorRoomid     int    `json:"anchor_roomid"`
+			AnchorUname      string `json:"anchor_uname"`
+			GuardLevel       int    `json:"guard_level"`
+			IconID           int    `json:"icon_id"`
+			IsLighted        int    `json:"is_lighted"`
+			MedalColor       int    `json:"medal_color"`
+			MedalColorBorder int    `json:"medal_color_border"`
+			MedalColorEnd    int    `json:"medal_color_end"`
+			MedalColorStart  int    `json:"medal_color_start"`
+			MedalLevel       int    `json:"medal_level"`
+			MedalName        string `json:"medal_name"`
+			Special          string `json:"special"`
+			TargetID         int    `json:"target_id"`
+		} `json:"medal_info"`
+		NameColor  string      `json:"name_color"`
+		RUname     string      `json:"r_uname"`
+		Ruid       int         `json:"ruid"`
+		SendMaster interface{} `json:"send_master"`
+		TotalNum   int         `json:"total_num"`
+		UID        int         `json:"uid"`
+		Uname      string      `json:"uname"`
+	} `json:"data"`
+}
+
+type OnlineRankCount struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Count int `json:"count"`
+	} `json:"data"`
+}
+
+type ShoppingCartShow struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Status int `json:"status"`
+	} `json:"data"`
+}
+
+type GoodsItem struct {
+	GoodsID             string `json:"goods_id"`
+	GoodsName           string `json:"goods_name"`
+	Source              int    `json:"source"`
+	GoodsIcon           string `json:"goods_icon"`
+	IsPreSale           int    `json:"is_pre_sale"`
+	CouponDiscountPrice string `json:"coupon_discount_price"`
+	SelectedText        string `json:"selected_text"`
+	IsGiftBuy           int    `json:"is_gift_buy"`
+	GoodsPrice          string `json:"goods_price"`
+	GoodsMaxPrice       string `json:"goods_max_price"`
+}
+
+type SelectedGoodsInfo struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		ChangeType int         `json:"change_type"`
+		Item       []GoodsItem `json:"item"`
+		Title      string      `json:"title"`
+	} `json:"data"`
+}
+
+type ShoppingExplainCard struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		GoodsItem
+		SaleStatus   int    `json:"sale_status"`
+		CouponName   string `json:"coupon_name"`
+		Status       int    `json:"status"`
+		H5URL        string `json:"h5_url"`
+		Timestamp    int64  `json:"timestamp"`
+		UniqueID     string `json:"unique_id"`
+		UID          int    `json:"uid"`
+		SellingPoint string `json:"selling_point"`
+		SeiStatus    int    `json:"sei_status"`
+		IsExclusive  bool   `json:"is_exclusive"`
+		CouponID     string `json:"coupon_id"`
+	} `json:"data"`
+}
+
+type ShoppingBubblesStyle struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		IntervalBetweenBubbles int    `json:"interval_between_bubbles"`
+		IntervalBetweenQueues  int    `json:"interval_between_queues"`
+		CycleTime              int    `json:"cycle_time"`
+		GoodsCount             int    `json:"goods_count"`
+		Checksum               string `json:"checksum"`
+		BubblesList            []struct {
+			Tag        string   `json:"tag"`
+			Name       string   `json:"name"`
+			Priority   int      `json:"priority"`
+			ShowBanner int      `json:"show_banner"`
+			GoodsList  []string `json:"goods_list"`
+		} `json:"bubbles_list"`
+	} `json:"data"`
+}
+
+type HotBuyNum struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		GoodsID string `json:"goods_id"`
+		Num     int    `json:"num"`
+	} `json:"data"`
+}
+
+type GotoBuyFlow struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Text string `json:"text"`
+	} `json:"data"`
+}
+
+type VoiceJoinRoomCountInfo struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		RoomID      int `json:"room_id"`
+		RootStatus  int `json:"root_status"`
+		RoomStatus  int `json:"room_status"`
+		ApplyCount  int `json:"apply_count"`
+		NotifyCount int `json:"notify_count"`
+		RedPoint    int `json:"red_point"`
+	} `json:"data"`
+	RoomID int `json:"room_id"`
+}
+
+type VoiceJoinList struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		RoomID     int `json:"room_id"`
+		Category   int `json:"category"`
+		ApplyCount int `json:"apply_count"`
+		RedPoint   int `json:"red_point"`
+		Refresh    int `json:"refresh"`
+	} `json:"data"`
+	RoomID int `json:"room_id"`
+}
+
+type VoiceJoinStatus struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		RoomID       int    `json:"room_id"`
+		Status       int    `json:"status"`
+		Channel      string `json:"channel"`
+		ChannelType  string `json:"channel_type"`
+		UID          int    `json:"uid"`
+		UserName     string `json:"user_name"`
+		HeadPic      string `json:"head_pic"`
+		Guard        int    `json:"guard"`
+		StartAt      int64  `json:"start_at"`
+		CurrentTime  int64  `json:"current_time"`
+		WebShareLink string `json:"web_share_link"`
+	} `json:"data"`
+	RoomID int `json:"room_id"`
+}
+
+type VideoConnectionJoinStart struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Status       int    `json:"status"`
+		InvitedUID   int    `json:"invited_uid"`
+		ChannelID    string `json:"channel_id"`
+		InvitedUname string `json:"invited_uname"`
+		InvitedFace  string `json:"invited_face"`
+		StartAt      int64  `json:"start_at"`
+		CurrentTime  int64  `json:"current_time"`
+	} `json:"data"`
+	Roomid int `json:"roomid"`
+}
+
+type VideoConnectionJoinEnd struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		ChannelID   string `json:"channel_id"`
+		StartAt     int64  `json:"start_at"`
+		Toast       string `json:"toast"`
+		CurrentTime int64  `json:"current_time"`
+	} `json:"data"`
+	Roomid int `json:"roomid"`
+}
+
+type VideoConnectionMsg struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		ChannelID   string `json:"channel_id"`
+		CurrentTime int64  `json:"current_time"`
+		Dmscore     int    `json:"dmscore"`
+		Toast       string `json:"toast"`
+	} `json:"data"`
+}
+
+type LiveOpenPlatformGame struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		MsgType              string `json:"msg_type"`
+		MsgSubType           string `json:"msg_sub_type"`
+		GameName             string `json:"game_name"`
+		GameCode             string `json:"game_code"`
+		GameID               string `json:"game_id"`
+		GameStatus           string `json:"game_status"`
+		GameMsg              string `json:"game_msg"`
+		GameConf             string `json:"game_conf"`
+		InteractivePanelConf string `json:"interactive_panel_conf"`
+		Timestamp            int64  `json:"timestamp"`
+		BlockUIDs            []int  `json:"block_uids"`
+	} `json:"data"`
+}
+
+type WidgetWishList struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		Wish []struct {
+			Type       int    `json:"type"`
+			GiftID     int    `json:"gift_id"`
+			GiftName   string `json:"gift_name"`
+			GiftImg    string `json:"gift_img"`
+			GiftPrice  int    `json:"gift_price"`
+			TargetNum  int    `json:"target_num"`
+			CurrentNum int    `json:"current_num"`
+		} `json:"wish"`
+		WishStatus int    `json:"wish_status"`
+		Sid        int    `json:"sid"`
+		WishName   string `json:"wish_name"`
+	} `json:"data"`
+}
+
+type GuardAchievementRoom struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		AnchorGuardAchieveLevel int    `json:"anchor_guard_achieve_level"`
+		CurrentAchievementLevel int    `json:"current_achievement_level"`
+		EventType               int    `json:"event_type"`
+		Face                    string `json:"face"`
+		FirstLineContent        string `json:"first_line_content"`
+		SecondLineContent       string `json:"second_line_content"`
+		IsFirst                 bool   `json:"is_first"`
+		IsFirstNew              bool   `json:"is_first_new"`
+		RoomID                  int    `json:"room_id"`
+	} `json:"data"`
+}
+
+type RoomSkinMsg struct {
+	Cmd         string `json:"cmd"`
+	SkinID      int    `json:"skin_id"`
+	Status      int    `json:"status"`
+	EndTime     int64  `json:"end_time"`
+	CurrentTime int64  `json:"current_time"`
+	OnlyLocal   bool   `json:"only_local"`
+}
+
+type ActivityBannerChange struct {
+	Cmd  string `json:"cmd"`
+	Data struct {
+		List []struct {
+			ID            int    `json:"id"`
+			Timestamp     int64  `json:"timestamp"`
+			Position      string `json:"position"`
+			ActivityTitle string `json:"activity_title"`
+			Cover         string `json:"cover"`
+			JumpURL       string `json:"jump_url"`
+			IsClose       int    `json:"is_close"`
+			Action        string `json:"action"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+type PkBattlePre struct {
+	Cmd       string `json:"cmd"`
+	PkStatus  int    `json:"pk_status"`
+	PkID      int    `json:"pk_id"`
+	Timestamp int64  `json:"timestamp"`
+	Data      struct {
+		BattleType int    `json:"battle_type"`
+		MatchType  int    `json:"match_type"`
+		Uname      string `json:"uname"`
+		Face       string `json:"face"`
+		UID        int    `json:"uid"`
+		RoomID     int    `json:"room_id"`
+		SeasonID   int    `json:"season_id"`
+		PreTimer   int    `json:"pre_timer"`
+	} `json:"data"`
+}
+
+type PkBattleStartNew struct {
+	Cmd       string `json:"cmd"`
+	PkID      int    `json:"pk_id"`
+	PkStatus  int    `json:"pk_status"`
+	Timestamp int64  `json:"timestamp"`
+	Data      struct {
+		BattleType  int   `json:"battle_type"`
+		PkStartTime int64 `json:"pk_start_time"`
+		PkEndTime   int64 `json:"pk_end_time"`
+		InitInfo    struct {
+			RoomID int `json:"room_id"`
+		} `json:"init_info"`
+		MatchInfo struct {
+			RoomID int `json:"room_id"`
+		} `json:"match_info"`
+	} `json:"data"`
+}
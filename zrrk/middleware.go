@@ -0,0 +1,147 @@
+package zrrk
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Middleware 在事件交给插件之前被依次调用，可以修改事件，返回 false 时丢弃事件。
+type Middleware func(event interface{}) (interface{}, bool)
+
+// Use 添加中间件，中间件按添加的顺序执行。
+func (b *Bot) Use(middlewares ...Middleware) {
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
+// applyMiddlewares 依次执行中间件，出现 panic 的中间件会被跳过。
+func (b *Bot) applyMiddlewares(event interface{}) (interface{}, bool) {
+	for _, mw := range b.middlewares {
+		next, ok := b.callMiddleware(mw, event)
+		if !ok {
+			return nil, false
+		}
+		event = next
+	}
+	return event, true
+}
+
+func (b *Bot) callMiddleware(mw Middleware, event interface{}) (next interface{}, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.ERROR(fmt.Sprintf("中间件处理 %T 失败: %v", event, r))
+			next, ok = event, true
+		}
+	}()
+	return mw(event)
+}
+
+// eventUser 返回事件中的用户，没有用户的事件返回 false。
+func eventUser(event interface{}) (User, bool) {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Struct {
+		return User{}, false
+	}
+	field := v.FieldByName("User")
+	if !field.IsValid() {
+		return User{}, false
+	}
+	user, ok := field.Interface().(User)
+	return user, ok
+}
+
+// withUser 返回替换了用户的事件副本。
+func withUser(event interface{}, user User) interface{} {
+	v := reflect.New(reflect.TypeOf(event)).Elem()
+	v.Set(reflect.ValueOf(event))
+	v.FieldByName("User").Set(reflect.ValueOf(user))
+	return v.Interface()
+}
+
+// Blocklist 丢弃来自指定用户的事件。
+func Blocklist(uids ...int) Middleware {
+	blocked := map[int]bool{}
+	for _, uid := range uids {
+		blocked[uid] = true
+	}
+	return func(event interface{}) (interface{}, bool) {
+		if user, ok := eventUser(event); ok && blocked[user.UID] {
+			return nil, false
+		}
+		return event, true
+	}
+}
+
+// Dedup 丢弃 window 内重复的事件，key 为 nil 时按事件的全部内容判断。
+func Dedup(window time.Duration, key func(event interface{}) string) Middleware {
+	if key == nil {
+		key = func(event interface{}) string {
+			data, _ := EncodeEvent(event)
+			return string(data)
+		}
+	}
+	var lock sync.Mutex
+	seen := map[string]time.Time{}
+	return func(event interface{}) (interface{}, bool) {
+		k := key(event)
+		now := time.Now()
+		lock.Lock()
+		defer lock.Unlock()
+		if t, ok := seen[k]; ok && now.Sub(t) < window {
+			return nil, false
+		}
+		seen[k] = now
+		if len(seen) > 10000 {
+			for k, t := range seen {
+				if now.Sub(t) >= window {
+					delete(seen, k)
+				}
+			}
+		}
+		return event, true
+	}
+}
+
+// EnrichUser 使用 lookup 补全事件中的用户信息，只填充事件中缺失的字段。
+func EnrichUser(lookup func(uid int) (User, bool)) Middleware {
+	return func(event interface{}) (interface{}, bool) {
+		user, ok := eventUser(event)
+		if !ok || user.UID == 0 {
+			return event, true
+		}
+		profile, ok := lookup(user.UID)
+		if !ok {
+			return event, true
+		}
+		if user.Name == "" {
+			user.Name = profile.Name
+		}
+		if user.Medal.Title == "" {
+			user.Medal = profile.Medal
+		}
+		return withUser(event, user), true
+	}
+}
+
+// SessionMiddleware 为缺少场次的事件补上当前场次。
+func (b *Bot) SessionMiddleware() Middleware {
+	return func(event interface{}) (interface{}, bool) {
+		v := reflect.ValueOf(event)
+		if v.Kind() != reflect.Struct {
+			return event, true
+		}
+		field := v.FieldByName("SessionID")
+		if !field.IsValid() || field.Kind() != reflect.String || field.String() != "" {
+			return event, true
+		}
+		id := b.session.ID()
+		if id == "" {
+			return event, true
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		copied.FieldByName("SessionID").SetString(id)
+		return copied.Interface(), true
+	}
+}
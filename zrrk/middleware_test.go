@@ -0,0 +1,30 @@
+package zrrk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMiddlewares(t *testing.T) {
+	b := New()
+	b.Use(
+		Blocklist(1),
+		Dedup(time.Minute, nil),
+		EnrichUser(func(uid int) (User, bool) {
+			return User{UID: uid, Name: "profile"}, true
+		}),
+	)
+	if _, ok := b.applyMiddlewares(DanmakuData{User: User{UID: 1}, Text: "hi"}); ok {
+		t.Fatal("blocked user should be dropped")
+	}
+	event, ok := b.applyMiddlewares(DanmakuData{User: User{UID: 2}, Text: "hi"})
+	if !ok || event.(DanmakuData).User.Name != "profile" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if _, ok := b.applyMiddlewares(DanmakuData{User: User{UID: 2}, Text: "hi"}); ok {
+		t.Fatal("duplicated event should be dropped")
+	}
+	if _, ok := b.applyMiddlewares(PopularityData{Value: 1}); !ok {
+		t.Fatal("events without user should pass")
+	}
+}
@@ -0,0 +1,31 @@
+package zrrk
+
+import "sync"
+
+// wishTracker 记录心愿单中每个礼物的进度，用于判断心愿是否刚刚达成。
+type wishTracker struct {
+	lock    sync.Mutex
+	current map[int]int
+}
+
+func newWishTracker() *wishTracker {
+	return &wishTracker{current: map[int]int{}}
+}
+
+// Update 更新心愿单的进度，返回本次更新中新达成的心愿。
+// 首次见到的心愿即使已经达成也不返回，避免重连时重复标记。
+func (t *wishTracker) Update(wishes []Wish) []Wish {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var reached []Wish
+	current := map[int]int{}
+	for _, w := range wishes {
+		current[w.GiftID] = w.Current
+		prev, ok := t.current[w.GiftID]
+		if ok && prev < w.Target && w.Reached() {
+			reached = append(reached, w)
+		}
+	}
+	t.current = current
+	return reached
+}
@@ -2,6 +2,7 @@ package zrrk
 
 import (
 	"fmt"
+	"time"
 )
 
 func (d *Medal) String() string {
@@ -28,12 +29,34 @@ func (u *User) String() string {
 	return fmt.Sprintf("%s %s%s", medalStr, space, userStr)
 }
 
+// Gift 中的 Price 为收到的礼物的单价，PaidPrice 为送礼者实际支付的单价。
+// 二者只在盲盒中不同，盲盒的 BlindBoxID 与 BlindBoxName 为盲盒本身。
 type Gift struct {
-	ID       int    `json:"giftId"`
-	Currency string `json:"typcurrencye"`
-	Name     string `json:"giftName"`
-	Count    int    `json:"count"`
-	Price    int    `json:"price"`
+	ID           int    `json:"giftId"`
+	Name         string `json:"giftName"`
+	Count        int    `json:"count"`
+	Price        Money  `json:"price"`
+	PaidPrice    Money  `json:"paidPrice"`
+	BlindBoxID   int    `json:"blindBoxId"`
+	BlindBoxName string `json:"blindBoxName"`
+}
+
+func (g *Gift) Currency() Currency {
+	return g.Price.Currency
+}
+
+// Total 为礼物的总价值。
+func (g *Gift) Total() Money {
+	return g.Price.Mul(g.Count)
+}
+
+func (g *Gift) IsBlindBox() bool {
+	return g.BlindBoxID != 0
+}
+
+// Profit 为盲盒的盈亏，正数表示开出的价值高于支付的价格。
+func (g *Gift) Profit() Money {
+	return g.Price.Sub(g.PaidPrice).Mul(g.Count)
 }
 
 type RoomBlockMsg struct {
@@ -48,29 +71,321 @@ type RoomBlockMsg struct {
 	Uname string `json:"uname"`
 }
 
+// GiftData 的 TID 为 SEND_GIFT 的 tid。GameCode 为礼物所属的互动玩法，
+// 对应的互动玩法消息晚于 SEND_GIFT 到达时为空，此时由 GameActionData.GiftTID 指出。
 type GiftData struct {
-	RoomID int  `json:"roomid"`
-	User   User `json:"user"`
-	Gift   Gift `json:"gift"`
+	RoomID       int    `json:"roomid"`
+	User         User   `json:"user"`
+	Gift         Gift   `json:"gift"`
+	TID          string `json:"tid"`
+	BatchComboID string `json:"batch_combo_id"`
+	GameCode     string `json:"game_code"`
+	SessionID    string `json:"session_id"`
+}
+
+// ComboData 为一次连击的汇总，Gift.Count 为连击送出的总数，Total 为实际的总价值。
+// Gift 的其余字段来自第一个包，盲盒或价格变化时 Gift.Price 乘以数量不等于 Total。
+type ComboData struct {
+	RoomID       int       `json:"roomid"`
+	User         User      `json:"user"`
+	Gift         Gift      `json:"gift"`
+	BatchComboID string    `json:"batch_combo_id"`
+	Total        Money     `json:"total"`
+	Packets      int       `json:"packets"`
+	Summary      bool      `json:"summary"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	SessionID    string    `json:"session_id"`
 }
+
+// Medal 的 RoomID 为粉丝牌所属主播的直播间，未知时为 0。
 type Medal struct {
-	Title string `json:"title"`
-	Level int    `json:"level"`
+	Title  string `json:"title"`
+	Level  int    `json:"level"`
+	RoomID int    `json:"roomid"`
 }
 type User struct {
 	UID   int    `json:"uid"`
 	Name  string `json:"name"`
 	Medal Medal  `json:"modal"`
 }
+
+// DanmakuData 的 GuardLevel 为发送者在本直播间的大航海等级，IsAdmin 表示发送者是否为房管。
 type DanmakuData struct {
-	User User   `json:"user"`
-	Text string `json:"text"`
+	RoomID     int        `json:"roomid"`
+	User       User       `json:"user"`
+	Text       string     `json:"text"`
+	GuardLevel GuardLevel `json:"guard_level"`
+	IsAdmin    bool       `json:"is_admin"`
+	SessionID  string     `json:"session_id"`
 }
 type SCData struct {
-	User User   `json:"user"`
-	Text string `json:"text"`
+	RoomID       int       `json:"roomid"`
+	ID           int       `json:"id"`
+	User         User      `json:"user"`
+	Text         string    `json:"text"`
+	MessageTrans string    `json:"message_trans"`
+	Price        Money     `json:"price"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	SessionID    string    `json:"session_id"`
+}
+type SCDeleteData struct {
+	RoomID  int   `json:"roomid"`
+	IDs     []int `json:"ids"`
+	Removed []int `json:"removed"`
+}
+type SCExpireData struct {
+	RoomID    int    `json:"roomid"`
+	IDs       []int  `json:"ids"`
+	SessionID string `json:"session_id"`
+}
+type SCTranslationData struct {
+	RoomID       int    `json:"roomid"`
+	ID           int    `json:"id"`
+	MessageTrans string `json:"message_trans"`
 }
 type InteractData struct {
-	User User `json:"user"`
-	Type int  `json:"type"`
+	RoomID     int       `json:"roomid"`
+	User       User      `json:"user"`
+	Type       int       `json:"type"`
+	Identities []int     `json:"identities"`
+	Grade      int       `json:"grade"`
+	SpreadInfo string    `json:"spread_info"`
+	Timestamp  time.Time `json:"timestamp"`
+	SessionID  string    `json:"session_id"`
+}
+
+// EntryEffectData 为带入场特效的进场，非大航海的特效 GuardLevel 为 GUARD_LEVEL_NONE。
+type EntryEffectData struct {
+	RoomID        int        `json:"roomid"`
+	User          User       `json:"user"`
+	GuardLevel    GuardLevel `json:"guard_level"`
+	PrivilegeType int        `json:"privilege_type"`
+	Text          string     `json:"text"`
+	Time          time.Time  `json:"time"`
+	SessionID     string     `json:"session_id"`
+}
+
+// NoticeData 为全站跑马灯，RealRoomID 为跑马灯所指向的直播间。
+type NoticeData struct {
+	RoomID     int    `json:"roomid"`
+	RealRoomID int    `json:"real_roomid"`
+	Name       string `json:"name"`
+	MsgType    int    `json:"msg_type"`
+	Text       string `json:"text"`
+	LinkURL    string `json:"link_url"`
+	SessionID  string `json:"session_id"`
+}
+type LiveStatusData struct {
+	RoomID  int     `json:"roomid"`
+	Status  int     `json:"status"`
+	Msg     string  `json:"msg"`
+	Session Session `json:"session"`
+}
+type RoomChangeData struct {
+	RoomID         int    `json:"roomid"`
+	Title          string `json:"title"`
+	AreaID         int    `json:"area_id"`
+	AreaName       string `json:"area_name"`
+	ParentAreaID   int    `json:"parent_area_id"`
+	ParentAreaName string `json:"parent_area_name"`
+	SessionID      string `json:"session_id"`
+}
+type ModerationData struct {
+	RoomID    int           `json:"roomid"`
+	Type      int           `json:"type"`
+	Operator  int           `json:"operator"`
+	User      User          `json:"user"`
+	Scope     string        `json:"scope"`
+	Level     int           `json:"level"`
+	Duration  time.Duration `json:"duration"`
+	EndTime   time.Time     `json:"end_time"`
+	Msg       string        `json:"msg"`
+	SessionID string        `json:"session_id"`
+}
+type AdminListData struct {
+	RoomID int   `json:"roomid"`
+	UIDs   []int `json:"uids"`
+}
+
+// GuardEvent 为一次大航海购买，GUARD_BUY 与 USER_TOAST_MSG 合并后只会发出一次。
+// 只收到 GUARD_BUY 时 OpType 为 GUARD_OP_UNKNOWN，无法区分开通和续费，IsFirst 为 false。
+type GuardEvent struct {
+	RoomID      int        `json:"roomid"`
+	User        User       `json:"user"`
+	Level       GuardLevel `json:"level"`
+	Unit        string     `json:"unit"`
+	Num         int        `json:"num"`
+	Price       Money      `json:"price"`
+	OpType      int        `json:"op_type"`
+	IsFirst     bool       `json:"is_first"`
+	IsAutoRenew bool       `json:"is_auto_renew"`
+	PayflowID   string     `json:"payflow_id"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     time.Time  `json:"end_time"`
+	SessionID   string     `json:"session_id"`
+}
+type RankUser struct {
+	User       User       `json:"user"`
+	Rank       int        `json:"rank"`
+	Score      int        `json:"score"`
+	GuardLevel GuardLevel `json:"guard_level"`
+}
+type OnlineRankData struct {
+	RoomID int        `json:"roomid"`
+	List   []RankUser `json:"list"`
+	Count  int        `json:"count"`
+}
+type OnlineRankChangeData struct {
+	RoomID  int      `json:"roomid"`
+	Type    int      `json:"type"`
+	User    RankUser `json:"user"`
+	OldRank int      `json:"old_rank"`
+}
+type WatchedData struct {
+	RoomID    int    `json:"roomid"`
+	Num       int    `json:"num"`
+	Text      string `json:"text"`
+	SessionID string `json:"session_id"`
+}
+type LikeData struct {
+	RoomID     int    `json:"roomid"`
+	ClickCount int    `json:"click_count"`
+	SessionID  string `json:"session_id"`
+}
+type PopularityData struct {
+	RoomID    int    `json:"roomid"`
+	Value     int    `json:"value"`
+	SessionID string `json:"session_id"`
+}
+type Goods struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Price    string `json:"price"`
+	MaxPrice string `json:"max_price"`
+	Icon     string `json:"icon"`
+}
+type GoodsData struct {
+	RoomID     int     `json:"roomid"`
+	Type       int     `json:"type"`
+	ChangeType int     `json:"change_type"`
+	Title      string  `json:"title"`
+	Goods      []Goods `json:"goods"`
+	SessionID  string  `json:"session_id"`
+}
+type ShoppingCartData struct {
+	RoomID    int    `json:"roomid"`
+	Show      bool   `json:"show"`
+	SessionID string `json:"session_id"`
+}
+type ShoppingBubble struct {
+	Tag      string   `json:"tag"`
+	Name     string   `json:"name"`
+	GoodsIDs []string `json:"goods_ids"`
+}
+type ShoppingBubblesData struct {
+	RoomID     int              `json:"roomid"`
+	GoodsCount int              `json:"goods_count"`
+	Bubbles    []ShoppingBubble `json:"bubbles"`
+	SessionID  string           `json:"session_id"`
+}
+type HotBuyData struct {
+	RoomID    int    `json:"roomid"`
+	GoodsID   string `json:"goods_id"`
+	Num       int    `json:"num"`
+	SessionID string `json:"session_id"`
+}
+type GotoBuyData struct {
+	RoomID    int    `json:"roomid"`
+	Text      string `json:"text"`
+	SessionID string `json:"session_id"`
+}
+type CoStreamData struct {
+	RoomID    int      `json:"roomid"`
+	Action    int      `json:"action"`
+	CoStream  CoStream `json:"co_stream"`
+	Text      string   `json:"text"`
+	SessionID string   `json:"session_id"`
+}
+type Wish struct {
+	Type      int    `json:"type"`
+	GiftID    int    `json:"gift_id"`
+	GiftName  string `json:"gift_name"`
+	GiftPrice Money  `json:"gift_price"`
+	Target    int    `json:"target"`
+	Current   int    `json:"current"`
+}
+
+func (w *Wish) Reached() bool {
+	return w.Target > 0 && w.Current >= w.Target
+}
+
+type WishListData struct {
+	RoomID    int    `json:"roomid"`
+	Name      string `json:"name"`
+	Status    int    `json:"status"`
+	Wishes    []Wish `json:"wishes"`
+	SessionID string `json:"session_id"`
+}
+type GuardAchievementData struct {
+	RoomID    int    `json:"roomid"`
+	Level     int    `json:"level"`
+	IsFirst   bool   `json:"is_first"`
+	Text      string `json:"text"`
+	SessionID string `json:"session_id"`
+}
+type RoomSkinData struct {
+	RoomID    int       `json:"roomid"`
+	SkinID    int       `json:"skin_id"`
+	Status    int       `json:"status"`
+	EndTime   time.Time `json:"end_time"`
+	SessionID string    `json:"session_id"`
+}
+type ActivityBanner struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Position string    `json:"position"`
+	JumpURL  string    `json:"jump_url"`
+	Action   string    `json:"action"`
+	IsClose  bool      `json:"is_close"`
+	Time     time.Time `json:"time"`
+}
+type ActivityBannerData struct {
+	RoomID    int              `json:"roomid"`
+	Banners   []ActivityBanner `json:"banners"`
+	SessionID string           `json:"session_id"`
+}
+
+// MilestoneData 在场次时间线上记录了一个里程碑时发出。
+type MilestoneData struct {
+	RoomID    int       `json:"roomid"`
+	Milestone Milestone `json:"milestone"`
+	SessionID string    `json:"session_id"`
+}
+type GameData struct {
+	RoomID    int       `json:"roomid"`
+	Action    int       `json:"action"`
+	GameName  string    `json:"game_name"`
+	GameCode  string    `json:"game_code"`
+	GameID    string    `json:"game_id"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+}
+
+// GameActionData 为观众在互动玩法中的操作，送礼时 Gift 不为空。
+// Paid 为 true 的礼物同时会以 SEND_GIFT 发出 GiftData，统计收入时应以 GiftData 为准。
+// 对应的 GiftData 先发出时，GiftTID 为其 TID，否则之后的 GiftData 会带上 GameCode。
+type GameActionData struct {
+	RoomID     int        `json:"roomid"`
+	Type       int        `json:"type"`
+	User       User       `json:"user"`
+	GuardLevel GuardLevel `json:"guard_level"`
+	Text       string     `json:"text"`
+	Gift       *Gift      `json:"gift"`
+	Paid       bool       `json:"paid"`
+	GiftTID    string     `json:"gift_tid"`
+	GameCode   string     `json:"game_code"`
+	Time       time.Time  `json:"time"`
+	SessionID  string     `json:"session_id"`
 }
@@ -0,0 +1,135 @@
+package zrrk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency 为金额的单位。
+type Currency int
+
+const (
+	CURRENCY_NONE     Currency = 0
+	CURRENCY_GOLD     Currency = 1 // 金瓜子，1000 金瓜子 = 1 元
+	CURRENCY_SILVER   Currency = 2 // 银瓜子，没有人民币价值
+	CURRENCY_RMB_CENT Currency = 3 // 人民币分
+)
+
+const (
+	GOLD_PER_YUAN = 1000
+	GOLD_PER_CENT = GOLD_PER_YUAN / 100
+)
+
+func (c Currency) String() string {
+	switch c {
+	case CURRENCY_GOLD:
+		return "gold"
+	case CURRENCY_SILVER:
+		return "silver"
+	case CURRENCY_RMB_CENT:
+		return "rmb_cent"
+	}
+	return "none"
+}
+
+func (c Currency) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Currency) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "gold":
+		*c = CURRENCY_GOLD
+	case "silver":
+		*c = CURRENCY_SILVER
+	case "rmb_cent":
+		*c = CURRENCY_RMB_CENT
+	case "none", "":
+		*c = CURRENCY_NONE
+	default:
+		return fmt.Errorf("unknown currency: %s", text)
+	}
+	return nil
+}
+
+// parseCoinType 将消息中的 coin_type 转为 Currency。
+func parseCoinType(coinType string) Currency {
+	switch coinType {
+	case "gold":
+		return CURRENCY_GOLD
+	case "silver":
+		return CURRENCY_SILVER
+	}
+	return CURRENCY_NONE
+}
+
+// Money 为带单位的金额，不同单位之间只能通过 Gold 和 RMBCent 换算。
+type Money struct {
+	Amount   int      `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func Gold(amount int) Money {
+	return Money{Amount: amount, Currency: CURRENCY_GOLD}
+}
+
+func Silver(amount int) Money {
+	return Money{Amount: amount, Currency: CURRENCY_SILVER}
+}
+
+func RMBCent(amount int) Money {
+	return Money{Amount: amount, Currency: CURRENCY_RMB_CENT}
+}
+
+func Yuan(amount int) Money {
+	return RMBCent(amount * 100)
+}
+
+// IsPaid 判断金额是否对应人民币。
+func (m Money) IsPaid() bool {
+	return m.Currency == CURRENCY_GOLD || m.Currency == CURRENCY_RMB_CENT
+}
+
+// Gold 返回以金瓜子计的金额，银瓜子等没有人民币价值的金额为 0。
+func (m Money) Gold() int {
+	switch m.Currency {
+	case CURRENCY_GOLD:
+		return m.Amount
+	case CURRENCY_RMB_CENT:
+		return m.Amount * GOLD_PER_CENT
+	}
+	return 0
+}
+
+// RMBCent 返回以人民币分计的金额，不足一分的部分被舍去。
+func (m Money) RMBCent() int {
+	return m.Gold() / GOLD_PER_CENT
+}
+
+func (m Money) Yuan() float64 {
+	return float64(m.Gold()) / GOLD_PER_YUAN
+}
+
+func (m Money) Mul(n int) Money {
+	m.Amount *= n
+	return m
+}
+
+// Sub 返回两个金额之差，单位不同时按金瓜子计算。
+func (m Money) Sub(other Money) Money {
+	if m.Currency == other.Currency {
+		m.Amount -= other.Amount
+		return m
+	}
+	return Gold(m.Gold() - other.Gold())
+}
+
+func (m Money) String() string {
+	switch m.Currency {
+	case CURRENCY_GOLD, CURRENCY_RMB_CENT:
+		return fmt.Sprintf("%.1fRMB", m.Yuan())
+	case CURRENCY_SILVER:
+		return fmt.Sprintf("%d银瓜子", m.Amount)
+	}
+	return fmt.Sprintf("%d", m.Amount)
+}
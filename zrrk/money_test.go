@@ -0,0 +1,41 @@
+package zrrk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyConversion(t *testing.T) {
+	if got := Yuan(30).Gold(); got != 30000 {
+		t.Fatalf("Yuan(30).Gold() = %d", got)
+	}
+	if got := Gold(1990).RMBCent(); got != 199 {
+		t.Fatalf("Gold(1990).RMBCent() = %d", got)
+	}
+	if got := Silver(100).Gold(); got != 0 {
+		t.Fatalf("Silver(100).Gold() = %d", got)
+	}
+	if got := Gold(5000).Sub(Yuan(1)); got != Gold(4000) {
+		t.Fatalf("Gold(5000).Sub(Yuan(1)) = %v", got)
+	}
+	if got := Gold(1000).Mul(3).String(); got != "3.0RMB" {
+		t.Fatalf("String() = %s", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(RMBCent(3000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":3000,"currency":"rmb_cent"}` {
+		t.Fatalf("unexpected json: %s", data)
+	}
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m != RMBCent(3000) {
+		t.Fatalf("unexpected money: %v", m)
+	}
+}
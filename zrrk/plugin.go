@@ -0,0 +1,151 @@
+package zrrk
+
+import (
+	"context"
+	"sync"
+)
+
+// RoomInfo 为插件初始化时可以得到的直播间信息。
+// Session 为初始化时的场次，之后的场次可以通过 CurrentSession 得到；
+// Output 与 HandleData 收到的输出通道相同，供插件在事件之外输出消息。
+type RoomInfo struct {
+	RoomID         int            `json:"roomid"`
+	Session        Session        `json:"session"`
+	CurrentSession func() Session `json:"-"`
+	Output         chan<- string  `json:"-"`
+}
+
+// PluginFactory 为每个直播间创建独立的插件实例。
+type PluginFactory func(roomID int) BotPlugin
+
+// SharedPlugin 由可以被多个机器人共享的插件实现。
+// 共享的插件不会被 SetRoom，应当从每个事件的 RoomID 得到直播间。
+type SharedPlugin interface {
+	Shared() bool
+}
+
+// PluginLifecycle 为插件可选实现的生命周期接口。
+// Init 在机器人开始运行时调用一次，ctx 在机器人退出时被取消，返回错误的插件不会被加载；
+// OnConnect 和 OnDisconnect 在每次接续和断开直播间时调用；Close 在机器人退出时调用。
+type PluginLifecycle interface {
+	Init(ctx context.Context, room RoomInfo) error
+	OnConnect()
+	OnDisconnect()
+	Close() error
+}
+
+func (b *Bot) initPlugins(ctx context.Context) {
+	room := RoomInfo{
+		RoomID:         b.RoomID,
+		Session:        b.session.Current(),
+		CurrentSession: b.session.Current,
+		Output:         b.outChannel,
+	}
+	plugins := b.plugins[:0]
+	for _, e := range b.plugins {
+		if p, ok := e.plugin.(PluginLifecycle); ok {
+			if err := p.Init(ctx, room); err != nil {
+				b.ERROR("插件初始化失败: ", err)
+				continue
+			}
+		}
+		plugins = append(plugins, e)
+	}
+	b.plugins = plugins
+}
+
+func (b *Bot) connectPlugins() {
+	for _, e := range b.plugins {
+		if p, ok := e.plugin.(PluginLifecycle); ok {
+			p.OnConnect()
+		}
+	}
+}
+
+func (b *Bot) disconnectPlugins() {
+	for _, e := range b.plugins {
+		if p, ok := e.plugin.(PluginLifecycle); ok {
+			p.OnDisconnect()
+		}
+	}
+}
+
+func (b *Bot) closePlugins() {
+	for _, e := range b.plugins {
+		if p, ok := e.plugin.(PluginLifecycle); ok {
+			if err := p.Close(); err != nil {
+				b.ERROR("插件关闭失败: ", err)
+			}
+		}
+	}
+}
+
+// SharedWorker 实现被多个机器人共享的插件的生命周期，插件嵌入它即可。
+// 它管理插件的一个后台协程：第一个机器人 Init 时启动，最后一个机器人 Close 时停止，
+// 停止时关闭 done 并等待协程返回。插件在创建时调用 Start 设置并启动协程。
+type SharedWorker struct {
+	lock    sync.Mutex
+	refs    int
+	run     func(done <-chan struct{})
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Start 设置后台协程并启动，已经在运行时不会重复启动。
+func (w *SharedWorker) Start(run func(done <-chan struct{})) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.run = run
+	w.start()
+}
+
+func (w *SharedWorker) start() {
+	if w.done != nil || w.run == nil {
+		return
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	w.done, w.stopped = done, stopped
+	go func() {
+		defer close(stopped)
+		w.run(done)
+	}()
+}
+
+// Done 返回当前协程的 done，协程停止后关闭；协程未运行时返回 nil。
+func (w *SharedWorker) Done() <-chan struct{} {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.done
+}
+
+func (w *SharedWorker) Init(ctx context.Context, room RoomInfo) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.refs++
+	w.start()
+	return nil
+}
+
+func (w *SharedWorker) OnConnect() {}
+
+func (w *SharedWorker) OnDisconnect() {}
+
+// Close 在最后一个机器人退出时停止协程，并等待其返回。
+func (w *SharedWorker) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.refs > 0 {
+		w.refs--
+	}
+	if w.refs > 0 || w.done == nil {
+		return nil
+	}
+	close(w.done)
+	<-w.stopped
+	w.done = nil
+	return nil
+}
+
+func (w *SharedWorker) Shared() bool {
+	return true
+}
@@ -0,0 +1,158 @@
+package blindbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+	"github.com/jannchie/zrrk/zrrk/plugin/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlindBoxPlugin 按直播间和用户累计开盲盒的盈亏，每秒合并写入 blind_box_stats。
+type BlindBoxPlugin struct {
+	zrrk.SharedWorker
+	RoomID   int
+	DB       *gorm.DB
+	statChan chan BlindBoxStat
+}
+
+// BlindBoxStat 为用户在直播间开盲盒的累计盈亏，金额单位为金瓜子。
+type BlindBoxStat struct {
+	RoomID    int       `gorm:"primaryKey"`
+	UID       int       `gorm:"primaryKey"`
+	Count     int       `gorm:"default:0"`
+	Paid      int       `gorm:"default:0"`
+	Value     int       `gorm:"default:0"`
+	UpdatedAt time.Time ``
+}
+
+func (s *BlindBoxStat) Profit() int {
+	return s.Value - s.Paid
+}
+
+// 配置文件中的 blindbox 插件由所有直播间共享，设置中的 dsn 为 PostgreSQL 数据库的连接串。
+func init() {
+	database.RegisterPlugin("blindbox", func(db *gorm.DB) zrrk.BotPlugin {
+		return New(db)
+	})
+}
+
+func New(db *gorm.DB) *BlindBoxPlugin {
+	db.AutoMigrate(&BlindBoxStat{})
+	p := &BlindBoxPlugin{
+		DB:       db,
+		statChan: make(chan BlindBoxStat, 100),
+	}
+	p.Start(p.run)
+	return p
+}
+
+func (p *BlindBoxPlugin) run(done <-chan struct{}) {
+	stats := map[[2]int]*BlindBoxStat{}
+	add := func(stat BlindBoxStat) {
+		key := [2]int{stat.RoomID, stat.UID}
+		if s, ok := stats[key]; ok {
+			s.Count += stat.Count
+			s.Paid += stat.Paid
+			s.Value += stat.Value
+		} else {
+			stats[key] = &stat
+		}
+	}
+	save := func() {
+		for key, stat := range stats {
+			if err := p.save(stat); err != nil {
+				log.Println(err)
+				continue
+			}
+			delete(stats, key)
+		}
+	}
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+	for {
+		select {
+		case stat := <-p.statChan:
+			add(stat)
+		case <-ticker.C:
+			save()
+		case <-done:
+			for len(p.statChan) > 0 {
+				add(<-p.statChan)
+			}
+			save()
+			return
+		}
+	}
+}
+
+func (p *BlindBoxPlugin) save(stat *BlindBoxStat) error {
+	stat.UpdatedAt = time.Now()
+	return p.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "room_id"}, {Name: "uid"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("blind_box_stats.count + excluded.count"),
+			"paid":       gorm.Expr("blind_box_stats.paid + excluded.paid"),
+			"value":      gorm.Expr("blind_box_stats.value + excluded.value"),
+			"updated_at": stat.UpdatedAt,
+		}),
+	}).Create(stat).Error
+}
+
+// UserStat 返回用户在直播间的盲盒盈亏。
+func (p *BlindBoxPlugin) UserStat(roomID, uid int) (BlindBoxStat, error) {
+	var stat BlindBoxStat
+	err := p.DB.Limit(1).Find(&stat, "room_id = ? AND uid = ?", roomID, uid).Error
+	return stat, err
+}
+
+// RoomStat 返回直播间所有用户的盲盒盈亏之和。
+func (p *BlindBoxPlugin) RoomStat(roomID int) (BlindBoxStat, error) {
+	stat := BlindBoxStat{RoomID: roomID}
+	err := p.DB.Model(&BlindBoxStat{}).
+		Select("COALESCE(SUM(count), 0) AS count, COALESCE(SUM(paid), 0) AS paid, COALESCE(SUM(value), 0) AS value").
+		Where("room_id = ?", roomID).
+		Scan(&stat).Error
+	return stat, err
+}
+
+func (p *BlindBoxPlugin) Subscriptions() []zrrk.Subscription {
+	return []zrrk.Subscription{
+		zrrk.On(zrrk.GiftData{}),
+		zrrk.On(zrrk.ComboData{}, zrrk.Summarized()),
+	}
+}
+
+func (p *BlindBoxPlugin) GetDescriptions() []string {
+	return []string{}
+}
+
+func (p *BlindBoxPlugin) SetRoom(id int) {
+	p.RoomID = id
+}
+
+func (p *BlindBoxPlugin) HandleData(input interface{}, channel chan<- string) {
+	switch data := input.(type) {
+	case zrrk.GiftData:
+		p.record(data.RoomID, data.User.UID, data.Gift)
+	case zrrk.ComboData:
+		if data.Summary {
+			p.record(data.RoomID, data.User.UID, data.Gift)
+		}
+	}
+}
+
+func (p *BlindBoxPlugin) record(roomID, uid int, gift zrrk.Gift) {
+	if !gift.IsBlindBox() {
+		return
+	}
+	p.statChan <- BlindBoxStat{
+		RoomID: roomID,
+		UID:    uid,
+		Count:  gift.Count,
+		Paid:   gift.PaidPrice.Mul(gift.Count).Gold(),
+		Value:  gift.Total().Gold(),
+	}
+}
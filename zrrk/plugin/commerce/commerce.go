@@ -0,0 +1,163 @@
+package commerce
+
+import (
+	"log"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+	"github.com/jannchie/zrrk/zrrk/plugin/database"
+	"gorm.io/gorm"
+)
+
+const (
+	EventListed    = "listed"
+	EventExplained = "explained"
+	EventHotBuy    = "hot_buy"
+	EventGotoBuy   = "goto_buy"
+	EventCartShow  = "cart_show"
+	EventCartHide  = "cart_hide"
+)
+
+// LiveRoomGoods 为直播间每一场带货的商品时间线。
+type LiveRoomGoods struct {
+	ID        int64     `gorm:"primaryKey"`
+	RoomID    int       `gorm:"index"`
+	SessionID string    `gorm:"index"`
+	Event     string    ``
+	GoodsID   string    `gorm:"index"`
+	GoodsName string    ``
+	Price     string    ``
+	Num       int       ``
+	Text      string    ``
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// CommercePlugin 将带货商品的上架、讲解、热卖和购物车的变化按场次写入 live_room_goods，
+// 每秒批量写入一次。
+type CommercePlugin struct {
+	zrrk.SharedWorker
+	RoomID    int
+	DB        *gorm.DB
+	goodsChan chan LiveRoomGoods
+}
+
+// 配置文件中的 commerce 插件由所有直播间共享，设置中的 dsn 为 PostgreSQL 数据库的连接串。
+func init() {
+	database.RegisterPlugin("commerce", func(db *gorm.DB) zrrk.BotPlugin {
+		return New(db)
+	})
+}
+
+func New(db *gorm.DB) *CommercePlugin {
+	db.AutoMigrate(&LiveRoomGoods{})
+	p := &CommercePlugin{
+		DB:        db,
+		goodsChan: make(chan LiveRoomGoods, 100),
+	}
+	p.Start(p.run)
+	return p
+}
+
+func (p *CommercePlugin) run(done <-chan struct{}) {
+	var goodsArray []LiveRoomGoods
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+	for {
+		select {
+		case goods := <-p.goodsChan:
+			goodsArray = append(goodsArray, goods)
+		case <-ticker.C:
+			if len(goodsArray) > 0 {
+				if err := p.DB.Create(&goodsArray).Error; err == nil {
+					goodsArray = []LiveRoomGoods{}
+				} else {
+					log.Println(err)
+				}
+			}
+		case <-done:
+			for len(p.goodsChan) > 0 {
+				goodsArray = append(goodsArray, <-p.goodsChan)
+			}
+			if len(goodsArray) > 0 {
+				if err := p.DB.Create(&goodsArray).Error; err != nil {
+					log.Println(err)
+				}
+			}
+			return
+		}
+	}
+}
+
+// Timeline 返回一场直播的商品时间线。
+func (p *CommercePlugin) Timeline(roomID int, sessionID string) ([]LiveRoomGoods, error) {
+	var timeline []LiveRoomGoods
+	err := p.DB.Order("created_at").Find(&timeline, "room_id = ? AND session_id = ?", roomID, sessionID).Error
+	return timeline, err
+}
+
+func (p *CommercePlugin) Subscriptions() []zrrk.Subscription {
+	return []zrrk.Subscription{
+		zrrk.On(zrrk.GoodsData{}),
+		zrrk.On(zrrk.HotBuyData{}),
+		zrrk.On(zrrk.GotoBuyData{}),
+		zrrk.On(zrrk.ShoppingCartData{}),
+	}
+}
+
+func (p *CommercePlugin) GetDescriptions() []string {
+	return []string{}
+}
+
+func (p *CommercePlugin) SetRoom(id int) {
+	p.RoomID = id
+}
+
+func (p *CommercePlugin) HandleData(input interface{}, channel chan<- string) {
+	now := time.Now()
+	switch data := input.(type) {
+	case zrrk.GoodsData:
+		event := EventListed
+		if data.Type == zrrk.GOODS_EXPLAINED {
+			event = EventExplained
+		}
+		for _, goods := range data.Goods {
+			p.goodsChan <- LiveRoomGoods{
+				RoomID:    data.RoomID,
+				SessionID: data.SessionID,
+				Event:     event,
+				GoodsID:   goods.ID,
+				GoodsName: goods.Name,
+				Price:     goods.Price,
+				CreatedAt: now,
+			}
+		}
+	case zrrk.HotBuyData:
+		p.goodsChan <- LiveRoomGoods{
+			RoomID:    data.RoomID,
+			SessionID: data.SessionID,
+			Event:     EventHotBuy,
+			GoodsID:   data.GoodsID,
+			Num:       data.Num,
+			CreatedAt: now,
+		}
+	case zrrk.GotoBuyData:
+		p.goodsChan <- LiveRoomGoods{
+			RoomID:    data.RoomID,
+			SessionID: data.SessionID,
+			Event:     EventGotoBuy,
+			Text:      data.Text,
+			CreatedAt: now,
+		}
+	case zrrk.ShoppingCartData:
+		event := EventCartHide
+		if data.Show {
+			event = EventCartShow
+		}
+		p.goodsChan <- LiveRoomGoods{
+			RoomID:    data.RoomID,
+			SessionID: data.SessionID,
+			Event:     event,
+			CreatedAt: now,
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package database 为写入 PostgreSQL 的插件提供共同的设置，
+// 数据库的连接串在配置文件中以插件设置的 dsn 给出。
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jannchie/zrrk/zrrk"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Settings 为写入数据库的插件共有的设置，DSN 为 PostgreSQL 数据库的连接串。
+type Settings struct {
+	DSN string `json:"dsn"`
+}
+
+// Open 连接插件设置中 dsn 指定的数据库，settings 不为 nil 时同时将设置解码到 settings。
+func Open(name string, raw json.RawMessage, settings interface{}) (*gorm.DB, error) {
+	var s Settings
+	if err := zrrk.DecodeSettings(raw, &s); err != nil {
+		return nil, err
+	}
+	if settings != nil {
+		if err := zrrk.DecodeSettings(raw, settings); err != nil {
+			return nil, err
+		}
+	}
+	if s.DSN == "" {
+		return nil, fmt.Errorf("%s 插件没有设置 dsn", name)
+	}
+	return gorm.Open(postgres.Open(s.DSN), &gorm.Config{})
+}
+
+// RegisterPlugin 登记只需要数据库的共享插件。
+func RegisterPlugin(name string, build func(db *gorm.DB) zrrk.BotPlugin) {
+	zrrk.RegisterSharedPlugin(name, func(raw json.RawMessage) (zrrk.BotPlugin, error) {
+		db, err := Open(name, raw, nil)
+		if err != nil {
+			return nil, err
+		}
+		return build(db), nil
+	})
+}
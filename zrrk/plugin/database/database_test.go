@@ -0,0 +1,26 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+)
+
+func TestOpenRequiresDSN(t *testing.T) {
+	settings := struct {
+		Interval zrrk.Duration `json:"interval"`
+	}{Interval: zrrk.Duration(time.Minute)}
+	_, err := Open("metric", json.RawMessage(`{"interval": "2m"}`), &settings)
+	if err == nil || !strings.Contains(err.Error(), "metric 插件没有设置 dsn") {
+		t.Fatal("missing dsn should be reported: ", err)
+	}
+	if time.Duration(settings.Interval) != time.Minute*2 {
+		t.Fatal("extra settings should be decoded: ", settings.Interval)
+	}
+	if _, err := Open("gift", nil, nil); err == nil {
+		t.Fatal("empty settings should be reported")
+	}
+}
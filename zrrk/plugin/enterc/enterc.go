@@ -1,7 +1,9 @@
 package enterc
 
 import (
+	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/jannchie/zrrk/zrrk"
@@ -9,7 +11,18 @@ import (
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+var (
+	DB     *gorm.DB
+	dbOnce sync.Once
+)
+
+// openDB 打开数据库，所有直播间的插件共用同一个连接。
+func openDB() {
+	dbOnce.Do(func() {
+		DB, _ = gorm.Open(sqlite.Open("./test.db"), &gorm.Config{})
+		DB.AutoMigrate(&EnterCounter{}, &EnterRecord{}, &FollowRecord{}, &GuardEnterRecord{})
+	})
+}
 
 type EnterCounter struct {
 	UID       int       `gorm:"primaryKey"`
@@ -27,19 +40,39 @@ type FollowRecord struct {
 	ID        int       `gorm:"primaryKey"`
 	RoomID    int       `gorm:"index"`
 	UID       int       ``
+	Type      int       `gorm:"default:2"`
 	CreatedAt time.Time ``
 }
+type GuardEnterRecord struct {
+	ID         int       `gorm:"primaryKey"`
+	RoomID     int       `gorm:"index"`
+	UID        int       `gorm:"index"`
+	GuardLevel int       ``
+	CreatedAt  time.Time ``
+}
 type EnterCounterPlugin struct {
 	RoomID int
 }
 
+func init() {
+	zrrk.RegisterPlugin("enterc", func(roomID int, settings json.RawMessage) (zrrk.BotPlugin, error) {
+		return New(), nil
+	})
+}
+
 func New() *EnterCounterPlugin {
 	p := EnterCounterPlugin{}
-	DB, _ = gorm.Open(sqlite.Open("./test.db"), &gorm.Config{})
-	DB.AutoMigrate(&EnterCounter{}, &EnterRecord{}, &FollowRecord{})
+	openDB()
 	return &p
 }
 
+func (p *EnterCounterPlugin) Subscriptions() []zrrk.Subscription {
+	return []zrrk.Subscription{
+		zrrk.On(zrrk.InteractData{}),
+		zrrk.On(zrrk.EntryEffectData{}),
+	}
+}
+
 func (p *EnterCounterPlugin) GetDescriptions() []string {
 	return []string{}
 }
@@ -49,10 +82,18 @@ func (p *EnterCounterPlugin) SetRoom(id int) {
 }
 
 func (p *EnterCounterPlugin) HandleData(input interface{}, channel chan<- string) {
-	data, ok := input.(zrrk.InteractData)
-	if !ok {
-		return
+	switch data := input.(type) {
+	case zrrk.InteractData:
+		p.handleInteract(data)
+	case zrrk.EntryEffectData:
+		if data.GuardLevel == zrrk.GUARD_LEVEL_NONE {
+			return
+		}
+		DB.Create(&GuardEnterRecord{UID: data.User.UID, RoomID: p.RoomID, GuardLevel: int(data.GuardLevel), CreatedAt: data.Time})
 	}
+}
+
+func (p *EnterCounterPlugin) handleInteract(data zrrk.InteractData) {
 	uid := data.User.UID
 	var enterCounter EnterCounter
 	_ = DB.Limit(1).Find(&enterCounter, "uid = ? AND room_id = ?", uid, p.RoomID)
@@ -74,7 +115,7 @@ func (p *EnterCounterPlugin) HandleData(input interface{}, channel chan<- string
 			}
 		}
 		DB.Create(&EnterRecord{UID: uid, RoomID: p.RoomID, CreatedAt: time.Now()})
-	case zrrk.INTERACT_FOLLOW:
-		DB.Create(&FollowRecord{UID: uid, RoomID: p.RoomID, CreatedAt: time.Now()})
+	case zrrk.INTERACT_FOLLOW, zrrk.INTERACT_SPECIAL_FOLLOW, zrrk.INTERACT_MUTUAL_FOLLOW:
+		DB.Create(&FollowRecord{UID: uid, RoomID: p.RoomID, Type: data.Type, CreatedAt: time.Now()})
 	}
 }
@@ -0,0 +1,281 @@
+// Package exec 将外部程序作为插件运行。
+//
+// 事件以 zrrk.EncodeEvent 的格式逐行写入外部程序的标准输入，第一行为 RoomInfo，
+// 重启时写入重启时的场次。外部程序未运行期间的事件会被丢弃。
+// 外部程序在标准输出中逐行写入 JSON 命令：
+//
+//	{"type": "message", "text": "..."}       输出一条消息
+//	{"type": "log", "text": "..."}           写入日志
+//	{"type": "descriptions", "texts": [...]} 更新插件说明
+//
+// 标准错误会被写入日志。外部程序退出后会在等待一段时间后重新启动。
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	osexec "os/exec"
+	"sync"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+)
+
+const maxRestartDelay = time.Minute
+
+// Settings 为配置文件中 exec 插件的设置。Events 为空时接收所有事件。
+type Settings struct {
+	Command      string        `json:"command"`
+	Args         []string      `json:"args"`
+	Dir          string        `json:"dir"`
+	Env          []string      `json:"env"`
+	Events       []string      `json:"events"`
+	RestartDelay zrrk.Duration `json:"restart_delay"`
+}
+
+type reply struct {
+	Type  string   `json:"type"`
+	Text  string   `json:"text"`
+	Texts []string `json:"texts"`
+}
+
+type ExecPlugin struct {
+	RoomID       int
+	Settings     Settings
+	lock         sync.Mutex
+	writeLock    sync.Mutex
+	stdin        io.WriteCloser
+	writeFailed  bool
+	room         zrrk.RoomInfo
+	descriptions []string
+	cancel       context.CancelFunc
+	stopped      chan struct{}
+}
+
+func init() {
+	zrrk.RegisterPlugin("exec", func(roomID int, raw json.RawMessage) (zrrk.BotPlugin, error) {
+		var settings Settings
+		if err := zrrk.DecodeSettings(raw, &settings); err != nil {
+			return nil, err
+		}
+		if settings.Command == "" {
+			return nil, errors.New("exec 插件没有设置 command")
+		}
+		return New(settings), nil
+	})
+}
+
+func New(settings Settings) *ExecPlugin {
+	if settings.RestartDelay <= 0 {
+		settings.RestartDelay = zrrk.Duration(time.Second)
+	}
+	return &ExecPlugin{Settings: settings}
+}
+
+func (p *ExecPlugin) Init(ctx context.Context, room zrrk.RoomInfo) error {
+	if _, err := osexec.LookPath(p.Settings.Command); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.room = room
+	p.cancel = cancel
+	p.stopped = make(chan struct{})
+	go p.supervise(ctx)
+	return nil
+}
+
+func (p *ExecPlugin) OnConnect() {}
+
+func (p *ExecPlugin) OnDisconnect() {}
+
+// Close 结束外部程序，并等待其退出。
+func (p *ExecPlugin) Close() error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	<-p.stopped
+	p.cancel = nil
+	return nil
+}
+
+// supervise 运行外部程序，并在其退出后重新启动。
+// 运行超过 maxRestartDelay 的程序退出后以初始间隔重启，否则间隔逐次翻倍，最长为 maxRestartDelay。
+func (p *ExecPlugin) supervise(ctx context.Context) {
+	defer close(p.stopped)
+	delay := time.Duration(p.Settings.RestartDelay)
+	for {
+		start := time.Now()
+		if err := p.run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[ROOM %10d] 外部插件 %s 退出: %v", p.RoomID, p.Settings.Command, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > maxRestartDelay {
+			delay = time.Duration(p.Settings.RestartDelay)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+// currentRoom 返回写入外部程序的 RoomInfo，其中的场次为当前的场次。
+func (p *ExecPlugin) currentRoom() zrrk.RoomInfo {
+	room := p.room
+	if room.CurrentSession != nil {
+		room.Session = room.CurrentSession()
+	}
+	return room
+}
+
+func (p *ExecPlugin) run(ctx context.Context) error {
+	cmd := osexec.CommandContext(ctx, p.Settings.Command, p.Settings.Args...)
+	cmd.Dir = p.Settings.Dir
+	cmd.Env = append(os.Environ(), p.Settings.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	p.stdin = stdin
+	p.writeFailed = false
+	p.lock.Unlock()
+	p.writeEvent(p.currentRoom())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.readReplies(ctx, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[ROOM %10d] 外部插件: %s", p.RoomID, scanner.Text())
+		}
+	}()
+	wg.Wait()
+	p.lock.Lock()
+	p.stdin = nil
+	p.lock.Unlock()
+	stdin.Close()
+	return cmd.Wait()
+}
+
+func (p *ExecPlugin) readReplies(ctx context.Context, stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r reply
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			log.Printf("[ROOM %10d] 外部插件输出无法解析: %s", p.RoomID, scanner.Text())
+			continue
+		}
+		switch r.Type {
+		case "message":
+			if p.room.Output == nil {
+				log.Printf("[ROOM %10d] 外部插件没有输出通道，丢弃输出: %s", p.RoomID, r.Text)
+				continue
+			}
+			select {
+			case p.room.Output <- r.Text:
+			case <-ctx.Done():
+				return
+			}
+		case "log":
+			log.Printf("[ROOM %10d] 外部插件: %s", p.RoomID, r.Text)
+		case "descriptions":
+			p.lock.Lock()
+			p.descriptions = r.Texts
+			p.lock.Unlock()
+		default:
+			log.Printf("[ROOM %10d] 外部插件输出未知命令: %s", p.RoomID, r.Type)
+		}
+	}
+}
+
+// writeEvent 写入一行事件，外部程序未运行时直接丢弃。
+// 写入失败通常是外部程序已经退出，每次启动只记录第一次失败。
+func (p *ExecPlugin) writeEvent(event interface{}) {
+	p.lock.Lock()
+	stdin := p.stdin
+	p.lock.Unlock()
+	if stdin == nil {
+		return
+	}
+	err := p.write(stdin, event)
+	if err == nil {
+		return
+	}
+	p.lock.Lock()
+	logged := p.writeFailed
+	p.writeFailed = true
+	p.lock.Unlock()
+	if !logged {
+		log.Printf("[ROOM %10d] 外部插件写入失败: %v", p.RoomID, err)
+	}
+}
+
+// write 写入一行事件。写入可能阻塞，不持有 p.lock，
+// 避免外部程序等待标准输出被读取时与 readReplies 互相等待。
+func (p *ExecPlugin) write(stdin io.Writer, event interface{}) error {
+	line, err := zrrk.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+	_, err = stdin.Write(append(line, '\n'))
+	return err
+}
+
+// Subscriptions 返回设置中的事件，未设置时订阅所有已登记的事件。
+func (p *ExecPlugin) Subscriptions() []zrrk.Subscription {
+	kinds := p.Settings.Events
+	if len(kinds) == 0 {
+		kinds = zrrk.EventKinds()
+	}
+	subs := make([]zrrk.Subscription, 0, len(kinds))
+	for _, kind := range kinds {
+		subs = append(subs, zrrk.Subscription{Kind: kind})
+	}
+	return subs
+}
+
+func (p *ExecPlugin) GetDescriptions() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.descriptions
+}
+
+func (p *ExecPlugin) SetRoom(id int) {
+	p.RoomID = id
+}
+
+// HandleData 将事件写入外部程序，外部程序重启期间的事件会被丢弃。
+func (p *ExecPlugin) HandleData(input interface{}, channel chan<- string) {
+	p.writeEvent(input)
+}
@@ -0,0 +1,119 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+)
+
+// script 每次启动时记录收到的 RoomInfo，回复说明和日志，收到一个事件后回复消息并退出。
+const script = `#!/bin/sh
+echo '{"type": "descriptions", "texts": ["外部插件"]}'
+read room
+echo "$room" >> "$1"
+echo '{"type": "log", "text": "started"}'
+read event
+echo '{"type": "message", "text": "pong"}'
+`
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for ", what)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestExecPlugin(t *testing.T) {
+	if _, err := osexec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plugin.sh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rooms := filepath.Join(dir, "rooms")
+	readRooms := func() []string {
+		data, _ := os.ReadFile(rooms)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+	var logs syncBuffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var lock sync.Mutex
+	session := zrrk.Session{ID: "first"}
+	out := make(chan string, 10)
+	p := New(Settings{Command: path, Args: []string{rooms}, RestartDelay: zrrk.Duration(time.Millisecond * 10)})
+	p.SetRoom(1)
+	err := p.Init(context.Background(), zrrk.RoomInfo{
+		RoomID: 1,
+		CurrentSession: func() zrrk.Session {
+			lock.Lock()
+			defer lock.Unlock()
+			return session
+		},
+		Output: out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	waitFor(t, "descriptions", func() bool { return len(p.GetDescriptions()) == 1 })
+	waitFor(t, "log", func() bool { return strings.Contains(logs.String(), "外部插件: started") })
+	lock.Lock()
+	session = zrrk.Session{ID: "second"}
+	lock.Unlock()
+	p.HandleData(zrrk.DanmakuData{RoomID: 1}, nil)
+	select {
+	case msg := <-out:
+		if msg != "pong" {
+			t.Fatal("unexpected message: ", msg)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for message")
+	}
+
+	waitFor(t, "restart", func() bool { return len(readRooms()) == 2 })
+	got := readRooms()
+	if !strings.Contains(got[0], `"id":"first"`) || !strings.Contains(got[1], `"id":"second"`) {
+		t.Fatal("unexpected rooms: ", got)
+	}
+	p.HandleData(zrrk.DanmakuData{RoomID: 1}, nil)
+	select {
+	case <-out:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for message after restart")
+	}
+}
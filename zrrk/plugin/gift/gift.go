@@ -2,60 +2,128 @@ package gift
 
 import (
 	"log"
-	"os"
 	"time"
 
 	"github.com/jannchie/zrrk/zrrk"
-	"gorm.io/driver/postgres"
+	"github.com/jannchie/zrrk/zrrk/plugin/database"
 	"gorm.io/gorm"
 )
 
+// GiftPlugin 将付费礼物、只以汇总发出的连击和大航海写入 live_room_gifts，
+// 每秒批量写入一次，互动玩法消息晚到时再补上礼物的玩法代码。
 type GiftPlugin struct {
+	zrrk.SharedWorker
 	RoomID   int
 	DB       *gorm.DB
-	giftChan chan LiveRoomGift `gorm:"-"`
+	giftChan chan interface{} `gorm:"-"`
 }
 
+// LiveRoomGift 中的金额单位均为金瓜子，TID 为 SEND_GIFT 的 tid。
 type LiveRoomGift struct {
 	ID        int64     `gorm:"primaryKey"`
 	RoomID    int       `gorm:"index"`
 	GiftID    int       ``
 	Price     int       ``
+	PaidPrice int       ``
 	Count     int       `gorm:"default:0"`
 	UID       int       ``
+	TID       string    `gorm:"index"`
+	SessionID string    `gorm:"index"`
+	GameCode  string    ``
 	CreatedAt time.Time ``
 }
 
-func New() *GiftPlugin {
-	dsn := os.Getenv("BILIBILI_DSN")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Println(err)
-	}
-	p := GiftPlugin{
+// gameLink 为先于互动玩法消息写入的礼物补上玩法代码。
+type gameLink struct {
+	TID      string
+	GameCode string
+}
+
+// 配置文件中的 gift 插件由所有直播间共享，设置中的 dsn 为 PostgreSQL 数据库的连接串。
+func init() {
+	database.RegisterPlugin("gift", func(db *gorm.DB) zrrk.BotPlugin {
+		return New(db)
+	})
+}
+
+func New(db *gorm.DB) *GiftPlugin {
+	db.AutoMigrate(&LiveRoomGift{})
+	p := &GiftPlugin{
 		DB:       db,
-		giftChan: make(chan LiveRoomGift, 100),
+		giftChan: make(chan interface{}, 100),
 	}
-	go func() {
-		var giftArray []LiveRoomGift
-		ticker := time.NewTicker(time.Second * 1)
-		defer ticker.Stop()
-		for {
-			select {
-			case gift := <-p.giftChan:
-				giftArray = append(giftArray, gift)
-			case <-ticker.C:
-				if len(giftArray) > 0 {
-					if err = p.DB.Create(&giftArray).Error; err == nil {
-						giftArray = []LiveRoomGift{}
-					} else {
-						log.Println(err)
-					}
-				}
+	p.Start(p.run)
+	return p
+}
+
+func (p *GiftPlugin) run(done <-chan struct{}) {
+	var gifts []LiveRoomGift
+	var links []gameLink
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+	receive := func(data interface{}) {
+		switch data := data.(type) {
+		case LiveRoomGift:
+			gifts = append(gifts, data)
+		case gameLink:
+			if !linkGift(gifts, data) {
+				links = append(links, data)
 			}
 		}
-	}()
-	return &p
+	}
+	flush := func() bool {
+		if len(gifts) > 0 {
+			if err := p.DB.Create(&gifts).Error; err != nil {
+				log.Println(err)
+				return false
+			}
+			gifts = nil
+		}
+		for len(links) > 0 {
+			link := links[0]
+			err := p.DB.Model(&LiveRoomGift{}).Where("tid = ?", link.TID).Update("game_code", link.GameCode).Error
+			if err != nil {
+				log.Println(err)
+				return false
+			}
+			links = links[1:]
+		}
+		return true
+	}
+	for {
+		select {
+		case data := <-p.giftChan:
+			receive(data)
+		case <-ticker.C:
+			flush()
+		case <-done:
+			for len(p.giftChan) > 0 {
+				receive(<-p.giftChan)
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// linkGift 为尚未写入的礼物补上玩法代码，返回是否找到了该礼物。
+func linkGift(gifts []LiveRoomGift, link gameLink) bool {
+	for i := range gifts {
+		if gifts[i].TID == link.TID {
+			gifts[i].GameCode = link.GameCode
+			return true
+		}
+	}
+	return false
+}
+
+func (p *GiftPlugin) Subscriptions() []zrrk.Subscription {
+	return []zrrk.Subscription{
+		zrrk.On(zrrk.GiftData{}),
+		zrrk.On(zrrk.ComboData{}, zrrk.Summarized()),
+		zrrk.On(zrrk.GuardEvent{}),
+		zrrk.On(zrrk.GameActionData{}),
+	}
 }
 
 func (p *GiftPlugin) GetDescriptions() []string {
@@ -67,18 +135,61 @@ func (p *GiftPlugin) SetRoom(id int) {
 }
 
 func (p *GiftPlugin) HandleData(input interface{}, channel chan<- string) {
-	data, ok := input.(zrrk.GiftData)
-	if !ok {
-		return
+	switch data := input.(type) {
+	case zrrk.GiftData:
+		p.handleGift(data)
+	case zrrk.ComboData:
+		p.handleCombo(data)
+	case zrrk.GameActionData:
+		if data.GiftTID == "" {
+			return
+		}
+		code := data.GameCode
+		if code == "" {
+			code = zrrk.GAME_CODE_INTERACTIVE
+		}
+		p.giftChan <- gameLink{TID: data.GiftTID, GameCode: code}
+	case zrrk.GuardEvent:
+		p.giftChan <- LiveRoomGift{
+			RoomID:    data.RoomID,
+			GiftID:    data.Level.GiftID(),
+			Count:     data.Num,
+			Price:     data.Price.Gold(),
+			PaidPrice: data.Price.Gold(),
+			UID:       data.User.UID,
+			SessionID: data.SessionID,
+		}
 	}
-	if data.Gift.Currency == "GOLD" {
+}
+
+func (p *GiftPlugin) handleGift(data zrrk.GiftData) {
+	if data.Gift.Price.IsPaid() {
 		var liveRoomGift = LiveRoomGift{
-			RoomID: data.RoomID,
-			GiftID: data.Gift.ID,
-			Count:  data.Gift.Count,
-			Price:  data.Gift.Price,
-			UID:    data.User.UID,
+			RoomID:    data.RoomID,
+			GiftID:    data.Gift.ID,
+			Count:     data.Gift.Count,
+			Price:     data.Gift.Price.Gold(),
+			PaidPrice: data.Gift.PaidPrice.Gold(),
+			UID:       data.User.UID,
+			TID:       data.TID,
+			SessionID: data.SessionID,
+			GameCode:  data.GameCode,
 		}
 		p.giftChan <- liveRoomGift
 	}
 }
+
+func (p *GiftPlugin) handleCombo(data zrrk.ComboData) {
+	if !data.Summary || !data.Gift.Price.IsPaid() {
+		return
+	}
+	p.giftChan <- LiveRoomGift{
+		RoomID:    data.RoomID,
+		GiftID:    data.Gift.ID,
+		Count:     data.Gift.Count,
+		Price:     data.Gift.Price.Gold(),
+		PaidPrice: data.Gift.PaidPrice.Gold(),
+		UID:       data.User.UID,
+		SessionID: data.SessionID,
+	}
+}
@@ -0,0 +1,39 @@
+package gift
+
+import (
+	"testing"
+
+	"github.com/jannchie/zrrk/zrrk"
+)
+
+func TestHandleSummarizedCombo(t *testing.T) {
+	p := &GiftPlugin{giftChan: make(chan interface{}, 10)}
+	combo := zrrk.ComboData{
+		RoomID: 1,
+		Gift:   zrrk.Gift{ID: 1, Count: 3, Price: zrrk.Gold(100), PaidPrice: zrrk.Gold(100)},
+		Total:  zrrk.Gold(300),
+	}
+	p.HandleData(combo, nil)
+	combo.Summary = true
+	p.HandleData(combo, nil)
+	if len(p.giftChan) != 1 {
+		t.Fatalf("stored %d rows, want 1", len(p.giftChan))
+	}
+	if row := (<-p.giftChan).(LiveRoomGift); row.Count != 3 || row.Price != 100 {
+		t.Fatal("unexpected row: ", row)
+	}
+}
+
+func TestGameActionLinksStoredGift(t *testing.T) {
+	p := &GiftPlugin{giftChan: make(chan interface{}, 10)}
+	p.HandleData(zrrk.GameActionData{RoomID: 1, Paid: true}, nil)
+	if len(p.giftChan) != 0 {
+		t.Fatal("game action without a linked gift should be ignored")
+	}
+	p.HandleData(zrrk.GameActionData{RoomID: 1, Paid: true, GiftTID: "tid"}, nil)
+	link := (<-p.giftChan).(gameLink)
+	gifts := []LiveRoomGift{{TID: "other"}, {TID: "tid"}}
+	if !linkGift(gifts, link) || gifts[1].GameCode != zrrk.GAME_CODE_INTERACTIVE || gifts[0].GameCode != "" {
+		t.Fatal("unexpected link: ", gifts)
+	}
+}
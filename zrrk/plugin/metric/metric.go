@@ -0,0 +1,184 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jannchie/zrrk/zrrk"
+	"github.com/jannchie/zrrk/zrrk/plugin/database"
+	"gorm.io/gorm"
+)
+
+// LiveRoomMetric 为直播间观众规模的采样，每个采样周期每个直播间最多一行，
+// 取值为周期内最后一次收到的数值，没有收到过的为 0。
+type LiveRoomMetric struct {
+	ID         int64     `gorm:"primaryKey"`
+	RoomID     int       `gorm:"index"`
+	SessionID  string    `gorm:"index"`
+	Watched    int       ``
+	Like       int       ``
+	Popularity int       ``
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// MetricPlugin 每隔 Interval 为每个直播间写入一行看过人数、点赞数和人气值的采样，
+// 场次切换时先写入上一场次的采样，机器人退出后不再为该直播间写入。
+type MetricPlugin struct {
+	zrrk.SharedWorker
+	RoomID     int
+	DB         *gorm.DB
+	Interval   time.Duration
+	metricChan chan interface{}
+}
+
+// Settings 为配置文件中 metric 插件的设置，除 dsn 外只有采样周期。
+// 所有直播间共享同一个实例，各直播间的设置必须相同。
+type Settings struct {
+	Interval zrrk.Duration `json:"interval"`
+}
+
+func init() {
+	zrrk.RegisterSharedPlugin("metric", func(raw json.RawMessage) (zrrk.BotPlugin, error) {
+		settings := Settings{Interval: zrrk.Duration(time.Minute)}
+		db, err := database.Open("metric", raw, &settings)
+		if err != nil {
+			return nil, err
+		}
+		return New(db, time.Duration(settings.Interval)), nil
+	})
+}
+
+// New 创建每隔 interval 采样一次的插件，interval 不为正数时为一分钟。
+func New(db *gorm.DB, interval time.Duration) *MetricPlugin {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	db.AutoMigrate(&LiveRoomMetric{})
+	p := &MetricPlugin{
+		DB:         db,
+		Interval:   interval,
+		metricChan: make(chan interface{}, 100),
+	}
+	p.Start(p.sample)
+	return p
+}
+
+// roomExit 在机器人退出时发送给采样协程，采样协程写入最后的数据后移除该直播间。
+type roomExit int
+
+func (p *MetricPlugin) sample(done <-chan struct{}) {
+	samples := map[int]*LiveRoomMetric{}
+	changed := map[int]bool{}
+	exited := map[int]bool{}
+	// pending 为场次切换时还未写入的上一场次的采样
+	var pending []LiveRoomMetric
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	flush := func() bool {
+		rows := pending
+		for roomID := range changed {
+			rows = append(rows, *samples[roomID])
+		}
+		now := time.Now()
+		for i := range rows {
+			rows[i].CreatedAt = now
+		}
+		if len(rows) > 0 {
+			if err := p.DB.Create(&rows).Error; err != nil {
+				log.Println(err)
+				return false
+			}
+		}
+		pending = nil
+		changed = map[int]bool{}
+		return true
+	}
+	for {
+		select {
+		case data := <-p.metricChan:
+			var roomID int
+			var sessionID string
+			switch data := data.(type) {
+			case roomExit:
+				exited[int(data)] = true
+				continue
+			case zrrk.WatchedData:
+				roomID, sessionID = data.RoomID, data.SessionID
+			case zrrk.LikeData:
+				roomID, sessionID = data.RoomID, data.SessionID
+			case zrrk.PopularityData:
+				roomID, sessionID = data.RoomID, data.SessionID
+			}
+			delete(exited, roomID)
+			sample, ok := samples[roomID]
+			if !ok || sample.SessionID != sessionID {
+				if ok && changed[roomID] {
+					pending = append(pending, *sample)
+				}
+				sample = &LiveRoomMetric{RoomID: roomID, SessionID: sessionID}
+				samples[roomID] = sample
+			}
+			switch data := data.(type) {
+			case zrrk.WatchedData:
+				sample.Watched = data.Num
+			case zrrk.LikeData:
+				sample.Like = data.ClickCount
+			case zrrk.PopularityData:
+				sample.Popularity = data.Value
+			}
+			changed[roomID] = true
+		case <-ticker.C:
+			if !flush() {
+				continue
+			}
+			for roomID := range exited {
+				delete(samples, roomID)
+			}
+			exited = map[int]bool{}
+		case <-done:
+			flush()
+			return
+		}
+	}
+}
+
+// Init 在机器人退出时通知采样协程移除该直播间。
+func (p *MetricPlugin) Init(ctx context.Context, room zrrk.RoomInfo) error {
+	if err := p.SharedWorker.Init(ctx, room); err != nil {
+		return err
+	}
+	done := p.Done()
+	go func() {
+		<-ctx.Done()
+		select {
+		case p.metricChan <- roomExit(room.RoomID):
+		case <-done:
+		}
+	}()
+	return nil
+}
+
+func (p *MetricPlugin) Subscriptions() []zrrk.Subscription {
+	return []zrrk.Subscription{
+		zrrk.On(zrrk.WatchedData{}),
+		zrrk.On(zrrk.LikeData{}),
+		zrrk.On(zrrk.PopularityData{}),
+	}
+}
+
+func (p *MetricPlugin) GetDescriptions() []string {
+	return []string{}
+}
+
+func (p *MetricPlugin) SetRoom(id int) {
+	p.RoomID = id
+}
+
+func (p *MetricPlugin) HandleData(input interface{}, channel chan<- string) {
+	switch input.(type) {
+	case zrrk.WatchedData, zrrk.LikeData, zrrk.PopularityData:
+		p.metricChan <- input
+	}
+}
@@ -1,9 +1,10 @@
 package todayrp
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/jannchie/zrrk/zrrk"
@@ -11,7 +12,18 @@ import (
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+var (
+	DB     *gorm.DB
+	dbOnce sync.Once
+)
+
+// openDB 打开数据库，所有直播间的插件共用同一个连接。
+func openDB() {
+	dbOnce.Do(func() {
+		DB, _ = gorm.Open(sqlite.Open("./test.db"), &gorm.Config{})
+		DB.AutoMigrate(&TodayRP{})
+	})
+}
 
 type TodayRP struct {
 	ID        int `gorm:"primaryKey"`
@@ -21,54 +33,65 @@ type TodayRP struct {
 }
 
 type TodayRPPlugin struct {
-	RoomID int
+	*zrrk.CommandRouter
 }
 
-func New() *TodayRPPlugin {
-	DB, _ = gorm.Open(sqlite.Open("./test.db"), &gorm.Config{})
-	DB.AutoMigrate(&TodayRP{})
-	p := TodayRPPlugin{}
-	return &p
+// Settings 为配置文件中 todayrp 插件的设置。
+type Settings struct {
+	Prefix string `json:"prefix"`
 }
 
-func (p *TodayRPPlugin) SetRoom(id int) {
-	p.RoomID = id
+func init() {
+	zrrk.RegisterPlugin("todayrp", func(roomID int, raw json.RawMessage) (zrrk.BotPlugin, error) {
+		settings := Settings{Prefix: "06"}
+		if err := zrrk.DecodeSettings(raw, &settings); err != nil {
+			return nil, err
+		}
+		return NewWithPrefix(settings.Prefix), nil
+	})
 }
 
-func (p *TodayRPPlugin) GetDescriptions() []string {
-	return []string{"输入“06+运势”，每天测一次运势吧！"}
+func New() *TodayRPPlugin {
+	return NewWithPrefix("06")
 }
 
-func (p *TodayRPPlugin) HandleData(input interface{}, channel chan<- string) {
-	data, ok := input.(zrrk.DanmakuData)
-	if !ok {
-		return
-	}
-	if !strings.Contains(data.Text, "06") {
-		return
-	}
-	if !zrrk.ContainStrings(data.Text, "RP", "rp", "人品", "求签", "抽签", "运") {
-		return
-	}
-	if data.User.UID == 0 {
-		return
+func NewWithPrefix(prefix string) *TodayRPPlugin {
+	openDB()
+	p := TodayRPPlugin{
+		CommandRouter: zrrk.NewCommandRouter(prefix),
 	}
+	p.Handle(&zrrk.Command{
+		Name:         "运势",
+		Aliases:      []string{"RP", "人品", "求签", "抽签", "运"},
+		Description:  fmt.Sprintf("输入“%s+运势”，每天测一次运势吧！", prefix),
+		MaxArgs:      -1,
+		UserCooldown: time.Second * 10,
+		Handler:      p.handleRP,
+	})
+	return &p
+}
+
+func (p *TodayRPPlugin) handleRP(ctx *zrrk.CommandContext) error {
+	user := ctx.Danmaku.User
 	var rp TodayRP
-	_ = DB.Limit(1).Order("created_at DESC").Find(&rp, "uid = ?", data.User.UID)
+	if err := DB.Limit(1).Order("created_at DESC").Find(&rp, "uid = ?", user.UID).Error; err != nil {
+		return err
+	}
 	isSameDay := zrrk.IsSameDay(rp.CreatedAt)
 	if isSameDay {
-		msg := fmt.Sprintf("%s今天已经测过，今天的运势是%d · %s。", data.User.Name, rp.RP, getRPLevel(rp.RP))
-		channel <- msg
-		return
+		ctx.Reply(fmt.Sprintf("%s今天已经测过，今天的运势是%d · %s。", user.Name, rp.RP, getRPLevel(rp.RP)))
+		return nil
 	}
-	rp.UID = data.User.UID
+	rp.UID = user.UID
 	rp.RP = int(rand.NormFloat64()*50 + 50)
-	DB.Create(&TodayRP{
+	if err := DB.Create(&TodayRP{
 		UID: rp.UID,
 		RP:  rp.RP,
-	})
-	msg := fmt.Sprintf("%s今天的运势是%d · %s。", data.User.Name, rp.RP, getRPLevel(rp.RP))
-	channel <- msg
+	}).Error; err != nil {
+		return err
+	}
+	ctx.Reply(fmt.Sprintf("%s今天的运势是%d · %s。", user.Name, rp.RP, getRPLevel(rp.RP)))
+	return nil
 }
 
 func getRPLevel(rp int) string {
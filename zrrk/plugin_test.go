@@ -0,0 +1,247 @@
+package zrrk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type roomPlugin struct {
+	roomID int
+	shared bool
+}
+
+func (p *roomPlugin) HandleData(data interface{}, channel chan<- string) {}
+func (p *roomPlugin) GetDescriptions() []string                          { return nil }
+func (p *roomPlugin) SetRoom(id int)                                     { p.roomID = id }
+func (p *roomPlugin) Shared() bool                                       { return p.shared }
+
+func TestAddPluginFactory(t *testing.T) {
+	factory := func(roomID int) BotPlugin {
+		return &roomPlugin{}
+	}
+	a, b := New(), New()
+	a.RoomID, b.RoomID = 1, 2
+	a.AddPluginFactory(factory)
+	b.AddPluginFactory(factory)
+	if got := a.plugins[0].plugin.(*roomPlugin).roomID; got != 1 {
+		t.Fatalf("room of a = %d", got)
+	}
+	if got := b.plugins[0].plugin.(*roomPlugin).roomID; got != 2 {
+		t.Fatalf("room of b = %d", got)
+	}
+}
+
+func TestAddSharedPlugin(t *testing.T) {
+	shared := &roomPlugin{shared: true}
+	a, b := New(), New()
+	a.RoomID, b.RoomID = 1, 2
+	a.AddPlugin(shared)
+	b.AddPlugin(shared)
+	if shared.roomID != 0 {
+		t.Fatalf("shared plugin should not be bound to room %d", shared.roomID)
+	}
+}
+
+type panicPlugin struct {
+	roomPlugin
+}
+
+func (p *panicPlugin) HandleData(data interface{}, channel chan<- string) {
+	panic("boom")
+}
+
+func TestDispatchRecoversAndDisables(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.PluginMaxFailures = 3
+	b.AddPlugin(&panicPlugin{})
+	for i := 0; i < 5; i++ {
+		b.dispatch(b.plugins[0], DanmakuData{})
+	}
+	stats := b.PluginStats()[0]
+	if stats.Panics != 3 || stats.Calls != 3 || !stats.Disabled {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+type slowPlugin struct {
+	roomPlugin
+}
+
+func (p *slowPlugin) HandleData(data interface{}, channel chan<- string) {
+	time.Sleep(time.Second)
+}
+
+func TestDispatchTimeout(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.PluginTimeout = time.Millisecond * 10
+	b.AddPlugin(&slowPlugin{})
+	start := time.Now()
+	b.dispatch(b.plugins[0], DanmakuData{})
+	if time.Since(start) > time.Millisecond*500 {
+		t.Fatal("dispatch should not wait for a slow plugin")
+	}
+	if stats := b.PluginStats()[0]; stats.Timeouts != 1 || stats.Disabled {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+type countingPlugin struct {
+	roomPlugin
+	lock    sync.Mutex
+	calls   int
+	running int
+	overlap bool
+}
+
+func (p *countingPlugin) HandleData(data interface{}, channel chan<- string) {
+	p.lock.Lock()
+	p.calls++
+	p.running++
+	p.overlap = p.overlap || p.running > 1
+	p.lock.Unlock()
+	time.Sleep(time.Millisecond * 100)
+	p.lock.Lock()
+	p.running--
+	p.lock.Unlock()
+}
+
+func TestDispatchSkipsWhileTimedOutCallRuns(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr + 1
+	b.PluginTimeout = time.Millisecond * 10
+	p := &countingPlugin{}
+	b.AddPlugin(p)
+	for i := 0; i < 3; i++ {
+		b.dispatch(b.plugins[0], DanmakuData{})
+	}
+	time.Sleep(time.Millisecond * 150)
+	b.dispatch(b.plugins[0], DanmakuData{})
+	time.Sleep(time.Millisecond * 150)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.overlap || p.calls != 2 {
+		t.Fatalf("calls = %d, overlap = %v", p.calls, p.overlap)
+	}
+	if stats := b.PluginStats()[0]; stats.Skipped != 2 || stats.Timeouts != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestBusySkipsDoNotDisablePlugin(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr + 1
+	b.PluginTimeout = time.Millisecond * 10
+	b.AddPlugin(&countingPlugin{})
+	for i := 0; i < defaultPluginMaxFailures+5; i++ {
+		b.dispatch(b.plugins[0], DanmakuData{})
+	}
+	stats := b.PluginStats()[0]
+	if stats.Disabled || stats.Failures != 1 || stats.Skipped != defaultPluginMaxFailures+4 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	time.Sleep(time.Millisecond * 150)
+	b.PluginTimeout = 0
+	b.dispatch(b.plugins[0], DanmakuData{})
+	if stats := b.PluginStats()[0]; stats.Disabled || stats.Failures != 0 || stats.Calls != 2 {
+		t.Fatalf("unexpected stats after the slow call returned: %+v", stats)
+	}
+}
+
+type giftOnlyPlugin struct {
+	roomPlugin
+}
+
+func (p *giftOnlyPlugin) Subscriptions() []Subscription {
+	return []Subscription{On(GiftData{}, MinPrice(Yuan(1)))}
+}
+
+func TestSubscriptions(t *testing.T) {
+	b := New()
+	b.AddPlugin(&giftOnlyPlugin{})
+	e := b.plugins[0]
+	if e.wants(DanmakuData{}) {
+		t.Fatal("should not want danmaku")
+	}
+	if e.wants(GiftData{Gift: Gift{Price: Gold(100), Count: 1}}) {
+		t.Fatal("should not want cheap gifts")
+	}
+	if !e.wants(GiftData{Gift: Gift{Price: Gold(100), Count: 10}}) {
+		t.Fatal("should want gifts worth 1 yuan")
+	}
+	b.kinds = b.subscribedKinds()
+	if b.wantsCommand("DANMU_MSG") || !b.wantsCommand("SEND_GIFT") || !b.wantsCommand("LIVE") {
+		t.Fatal("unexpected command filtering")
+	}
+	b.AddPlugin(&roomPlugin{})
+	b.kinds = b.subscribedKinds()
+	if !b.wantsCommand("DANMU_MSG") {
+		t.Fatal("plugins without subscriptions want every command")
+	}
+}
+
+func TestGetCMD(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"cmd":"DANMU_MSG","info":[]}`: "DANMU_MSG",
+		`{"data":{},"cmd":"LIVE"}`:      "LIVE",
+	} {
+		if got, _ := getCMD([]byte(body)); got != want {
+			t.Fatalf("getCMD(%s) = %s", body, got)
+		}
+	}
+}
+
+func TestSharedWorker(t *testing.T) {
+	var w SharedWorker
+	runs := make(chan struct{}, 10)
+	stops := make(chan struct{}, 10)
+	w.Start(func(done <-chan struct{}) {
+		runs <- struct{}{}
+		<-done
+		stops <- struct{}{}
+	})
+	room := RoomInfo{}
+	w.Init(context.Background(), room)
+	w.Init(context.Background(), room)
+	w.Close()
+	if len(stops) != 0 || w.Done() == nil {
+		t.Fatal("worker stopped while a bot still uses it")
+	}
+	w.Close()
+	if len(stops) != 1 || w.Done() != nil {
+		t.Fatal("worker should stop when the last bot closes")
+	}
+	w.Init(context.Background(), room)
+	w.Close()
+	if len(runs) != 2 || len(stops) != 2 {
+		t.Fatalf("runs = %d, stops = %d", len(runs), len(stops))
+	}
+}
+
+func TestActivityCountIgnoresSubscriptions(t *testing.T) {
+	var body []byte
+	for _, packet := range []string{
+		`{"cmd":"SEND_GIFT","data":{"giftId":1,"giftName":"辣条","num":1,"price":100,"coin_type":"silver","uid":1,"uname":"user"}}`,
+		`{"cmd":"INTERACT_WORD","data":{"uid":1,"uname":"user","msg_type":1}}`,
+		`{"cmd":"WATCHED_CHANGE","data":{"num":1}}`,
+	} {
+		body = append(body, HeadGen(len(packet), 5, 0)...)
+		body = append(body, packet...)
+	}
+	counts := map[string]int32{}
+	for name, kinds := range map[string]map[string]bool{"none": {}, "all": nil} {
+		b := New()
+		b.Lock = &sync.Mutex{}
+		b.LogLevel = LogErr
+		b.kinds = kinds
+		counts[name] = b.handleCommands(body)
+	}
+	if counts["none"] != 2 || counts["all"] != 2 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+}
@@ -0,0 +1,232 @@
+package zrrk
+
+import (
+	"bufio"
+	"os"
+	"sync"
+)
+
+// 未设置策略时与 QUEUE_DROP_OLDEST 相同。
+const (
+	QUEUE_DROP_OLDEST = 1 // 队列满时丢弃最早的事件
+	QUEUE_DROP_NEWEST = 2 // 队列满时丢弃新到达的事件
+	QUEUE_BLOCK       = 3 // 队列满时等待，会拖慢接收消息
+	QUEUE_SPILL       = 4 // 队列满时将事件写入磁盘，之后按顺序读回
+)
+
+const defaultQueueSize = 1000
+
+// QueueConfig 为插件事件队列的配置，Size 为内存中最多缓存的事件数。
+type QueueConfig struct {
+	Size     int
+	Policy   int
+	SpillDir string
+}
+
+// QueuePlugin 由需要单独设置队列的插件实现，返回值中为零的字段使用机器人的配置。
+type QueuePlugin interface {
+	QueueConfig() QueueConfig
+}
+
+// QueueStats 为队列的状态，Depth 包括写入磁盘尚未读回的事件。
+type QueueStats struct {
+	Depth   int   `json:"depth"`
+	Dropped int64 `json:"dropped"`
+	Spilled int64 `json:"spilled"`
+}
+
+// eventQueue 为有界的事件队列，一个写入者和一个读取者。
+type eventQueue struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	config  QueueConfig
+	items   []interface{}
+	closed  bool
+	dropped int64
+	spilled int64
+	spill   *spillFile
+	spillTo string
+}
+
+func newEventQueue(config QueueConfig, spillPath string) *eventQueue {
+	if config.Size <= 0 {
+		config.Size = defaultQueueSize
+	}
+	q := &eventQueue{config: config, spillTo: spillPath}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// push 按队列的策略放入事件，返回是否有事件因此被丢弃。
+func (q *eventQueue) push(event interface{}) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return true
+	}
+	if q.spill != nil && q.spill.pending > 0 {
+		return q.pushSpill(event)
+	}
+	for len(q.items) >= q.config.Size {
+		switch q.config.Policy {
+		case QUEUE_BLOCK:
+			q.cond.Wait()
+			if q.closed {
+				return true
+			}
+			continue
+		case QUEUE_DROP_NEWEST:
+			q.dropped++
+			return true
+		case QUEUE_SPILL:
+			return q.pushSpill(event)
+		default:
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.dropped++
+			q.items = append(q.items, event)
+			q.cond.Broadcast()
+			return true
+		}
+	}
+	q.items = append(q.items, event)
+	q.cond.Broadcast()
+	return false
+}
+
+func (q *eventQueue) pushSpill(event interface{}) bool {
+	if q.spill == nil {
+		spill, err := openSpillFile(q.spillTo)
+		if err != nil {
+			q.dropped++
+			return true
+		}
+		q.spill = spill
+	}
+	if err := q.spill.write(event); err != nil {
+		q.dropped++
+		return true
+	}
+	q.spilled++
+	q.cond.Broadcast()
+	return false
+}
+
+// pop 取出下一个事件，队列关闭且为空时返回 false。
+func (q *eventQueue) pop() (interface{}, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		if len(q.items) > 0 {
+			event := q.items[0]
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.cond.Broadcast()
+			return event, true
+		}
+		if q.spill != nil && q.spill.pending > 0 {
+			event, err := q.spill.read()
+			if err != nil {
+				q.dropped++
+				continue
+			}
+			return event, true
+		}
+		if q.closed {
+			if q.spill != nil {
+				q.spill.remove()
+				q.spill = nil
+			}
+			return nil, false
+		}
+		q.cond.Wait()
+	}
+}
+
+// close 关闭队列，已经在队列中的事件仍然可以被取出。
+func (q *eventQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *eventQueue) Stats() QueueStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	depth := len(q.items)
+	if q.spill != nil {
+		depth += q.spill.pending
+	}
+	return QueueStats{Depth: depth, Dropped: q.dropped, Spilled: q.spilled}
+}
+
+// spillFile 为写入磁盘的事件，全部读回后文件被清空。
+type spillFile struct {
+	path    string
+	writer  *os.File
+	reader  *bufio.Reader
+	file    *os.File
+	pending int
+}
+
+func openSpillFile(path string) (*spillFile, error) {
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return &spillFile{
+		path:   path,
+		writer: writer,
+		file:   file,
+		reader: bufio.NewReader(file),
+	}, nil
+}
+
+func (s *spillFile) write(event interface{}) error {
+	line, err := EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+	if _, err := s.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.pending++
+	return nil
+}
+
+func (s *spillFile) read() (interface{}, error) {
+	line, err := s.reader.ReadBytes('\n')
+	if err != nil {
+		// 文件已经损坏，放弃其中剩余的事件
+		s.pending = 0
+		s.reset()
+		return nil, err
+	}
+	s.pending--
+	if s.pending == 0 {
+		s.reset()
+	}
+	return DecodeEvent(line)
+}
+
+// reset 在事件全部读回后清空文件，避免文件无限增长。
+func (s *spillFile) reset() {
+	if err := s.writer.Truncate(0); err != nil {
+		return
+	}
+	s.writer.Seek(0, 0)
+	s.file.Seek(0, 0)
+	s.reader.Reset(s.file)
+}
+
+func (s *spillFile) remove() {
+	s.writer.Close()
+	s.file.Close()
+	os.Remove(s.path)
+}
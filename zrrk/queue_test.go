@@ -0,0 +1,49 @@
+package zrrk
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEventQueueDrop(t *testing.T) {
+	oldest := newEventQueue(QueueConfig{Size: 2, Policy: QUEUE_DROP_OLDEST}, "")
+	newest := newEventQueue(QueueConfig{Size: 2, Policy: QUEUE_DROP_NEWEST}, "")
+	for i := 1; i <= 3; i++ {
+		oldest.push(PopularityData{Value: i})
+		newest.push(PopularityData{Value: i})
+	}
+	if e, _ := oldest.pop(); e.(PopularityData).Value != 2 {
+		t.Fatalf("drop oldest should keep the latest events, got %v", e)
+	}
+	if e, _ := newest.pop(); e.(PopularityData).Value != 1 {
+		t.Fatalf("drop newest should keep the earliest events, got %v", e)
+	}
+	if oldest.Stats().Dropped != 1 || newest.Stats().Dropped != 1 {
+		t.Fatal("dropped events should be counted")
+	}
+}
+
+func TestEventQueueSpill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	q := newEventQueue(QueueConfig{Size: 1, Policy: QUEUE_SPILL}, path)
+	for i := 1; i <= 4; i++ {
+		q.push(GiftData{RoomID: i, Gift: Gift{Price: Gold(i * 100)}})
+	}
+	if stats := q.Stats(); stats.Depth != 4 || stats.Spilled != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	q.close()
+	for i := 1; i <= 4; i++ {
+		e, ok := q.pop()
+		if !ok {
+			t.Fatalf("missing event %d", i)
+		}
+		gift := e.(GiftData)
+		if gift.RoomID != i || gift.Gift.Price != Gold(i*100) {
+			t.Fatalf("unexpected event: %+v", gift)
+		}
+	}
+	if _, ok := q.pop(); ok {
+		t.Fatal("queue should be empty")
+	}
+}
@@ -0,0 +1,77 @@
+package zrrk
+
+import "sync"
+
+// OnlineRank 维护直播间的高能榜。
+type OnlineRank struct {
+	lock   sync.RWMutex
+	list   []RankUser
+	count  int
+	seeded bool
+}
+
+func NewOnlineRank() *OnlineRank {
+	return &OnlineRank{}
+}
+
+// Update 替换当前榜单，返回进入、离开和名次变化的用户。
+// 第一次收到的榜单只作为初始状态，不返回变化。
+func (r *OnlineRank) Update(list []RankUser) []OnlineRankChangeData {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.seeded {
+		r.seeded = true
+		r.list = list
+		return nil
+	}
+	old := make(map[int]RankUser, len(r.list))
+	for _, u := range r.list {
+		old[u.User.UID] = u
+	}
+	var changes []OnlineRankChangeData
+	for _, u := range list {
+		o, ok := old[u.User.UID]
+		switch {
+		case !ok:
+			changes = append(changes, OnlineRankChangeData{Type: RANK_CHANGE_ENTER, User: u})
+		case o.Rank != u.Rank:
+			changes = append(changes, OnlineRankChangeData{Type: RANK_CHANGE_MOVE, User: u, OldRank: o.Rank})
+		}
+		delete(old, u.User.UID)
+	}
+	for _, u := range r.list {
+		if _, ok := old[u.User.UID]; ok {
+			changes = append(changes, OnlineRankChangeData{Type: RANK_CHANGE_LEAVE, User: u, OldRank: u.Rank})
+		}
+	}
+	r.list = list
+	return changes
+}
+
+func (r *OnlineRank) SetCount(count int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.count = count
+}
+
+func (r *OnlineRank) List() []RankUser {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]RankUser{}, r.list...)
+}
+
+func (r *OnlineRank) Top3() []RankUser {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	n := len(r.list)
+	if n > 3 {
+		n = 3
+	}
+	return append([]RankUser{}, r.list[:n]...)
+}
+
+func (r *OnlineRank) Count() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.count
+}
@@ -0,0 +1,43 @@
+package zrrk
+
+import "testing"
+
+func rankUsers(uids ...int) []RankUser {
+	list := make([]RankUser, len(uids))
+	for i, uid := range uids {
+		list[i] = RankUser{User: User{UID: uid}, Rank: i + 1}
+	}
+	return list
+}
+
+func TestOnlineRankUpdate(t *testing.T) {
+	r := NewOnlineRank()
+	if changes := r.Update(rankUsers(1, 2, 3)); len(changes) != 0 {
+		t.Fatal("first snapshot should be silent: ", changes)
+	}
+	if len(r.List()) != 3 {
+		t.Fatal("first snapshot should be kept")
+	}
+	changes := r.Update(rankUsers(2, 1, 4))
+	want := map[int]struct {
+		kind    int
+		oldRank int
+	}{
+		2: {RANK_CHANGE_MOVE, 2},
+		1: {RANK_CHANGE_MOVE, 1},
+		4: {RANK_CHANGE_ENTER, 0},
+		3: {RANK_CHANGE_LEAVE, 3},
+	}
+	if len(changes) != len(want) {
+		t.Fatal("unexpected changes: ", changes)
+	}
+	for _, c := range changes {
+		w, ok := want[c.User.User.UID]
+		if !ok || c.Type != w.kind || c.OldRank != w.oldRank {
+			t.Fatal("unexpected change: ", c)
+		}
+	}
+	if changes := r.Update(rankUsers(2, 1, 4)); len(changes) != 0 {
+		t.Fatal("unchanged snapshot should not report changes: ", changes)
+	}
+}
@@ -19,3 +19,16 @@ type DanmakuInfoResp struct {
 		} `json:"host_list"`
 	} `json:"data"`
 }
+
+type RoomInitResp struct {
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+	Message string `json:"message"`
+	Data    struct {
+		RoomID     int   `json:"room_id"`
+		ShortID    int   `json:"short_id"`
+		UID        int   `json:"uid"`
+		LiveStatus int   `json:"live_status"`
+		LiveTime   int64 `json:"live_time"`
+	} `json:"data"`
+}
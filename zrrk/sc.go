@@ -0,0 +1,128 @@
+package zrrk
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SCBoard 维护直播间当前置顶的 SC 列表。
+type SCBoard struct {
+	lock  sync.Mutex
+	items map[int]SCData
+}
+
+func NewSCBoard() *SCBoard {
+	return &SCBoard{
+		items: map[int]SCData{},
+	}
+}
+
+// Add 将 SC 加入列表，已有的翻译不会被没有翻译的同一条 SC 覆盖。
+func (sb *SCBoard) Add(sc SCData) SCData {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+	if old, ok := sb.items[sc.ID]; ok && sc.MessageTrans == "" {
+		sc.MessageTrans = old.MessageTrans
+	}
+	sb.items[sc.ID] = sc
+	return sc
+}
+
+func (sb *SCBoard) Delete(ids ...int) []SCData {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+	var removed []SCData
+	for _, id := range ids {
+		if sc, ok := sb.items[id]; ok {
+			removed = append(removed, sc)
+			delete(sb.items, id)
+		}
+	}
+	return removed
+}
+
+// Translate 按 ID 合并翻译，返回合并后的 SC 以及该 SC 是否在列表中。
+func (sb *SCBoard) Translate(id int, text string) (SCData, bool) {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+	sc, ok := sb.items[id]
+	if !ok {
+		return sc, false
+	}
+	sc.MessageTrans = text
+	sb.items[id] = sc
+	return sc, true
+}
+
+// Expire 移除并返回在 now 之前结束的 SC。
+func (sb *SCBoard) Expire(now time.Time) []SCData {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+	var expired []SCData
+	for id, sc := range sb.items {
+		if scEnded(sc, now) {
+			expired = append(expired, sc)
+			delete(sb.items, id)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].ID < expired[j].ID
+	})
+	return expired
+}
+
+func scEnded(sc SCData, now time.Time) bool {
+	return !sc.EndTime.IsZero() && !now.Before(sc.EndTime)
+}
+
+// List 返回当前置顶的 SC，与观众看到的顺序一致：价格高的在前，同价格先发的在前。
+// 已经结束但还没有被 Expire 移除的 SC 不在其中。
+func (sb *SCBoard) List() []SCData {
+	sb.lock.Lock()
+	defer sb.lock.Unlock()
+	now := time.Now()
+	list := make([]SCData, 0, len(sb.items))
+	for _, sc := range sb.items {
+		if !scEnded(sc, now) {
+			list = append(list, sc)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Price.Gold() != list[j].Price.Gold() {
+			return list[i].Price.Gold() > list[j].Price.Gold()
+		}
+		if !list[i].StartTime.Equal(list[j].StartTime) {
+			return list[i].StartTime.Before(list[j].StartTime)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// expireSC 定期移除结束的 SC，并发出 SCExpireData。
+func (b *Bot) expireSC(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			expired := b.scBoard.Expire(now)
+			if len(expired) == 0 {
+				continue
+			}
+			ids := make([]int, len(expired))
+			for i, sc := range expired {
+				ids[i] = sc.ID
+			}
+			b.dataChan <- SCExpireData{
+				RoomID:    b.RoomID,
+				IDs:       ids,
+				SessionID: b.session.ID(),
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
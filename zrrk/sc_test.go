@@ -0,0 +1,68 @@
+package zrrk
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSCBoard(t *testing.T) {
+	board := NewSCBoard()
+	now := time.Now()
+	board.Add(SCData{ID: 1, Price: Yuan(30), StartTime: now, EndTime: now.Add(time.Minute)})
+	board.Add(SCData{ID: 2, Price: Yuan(50), StartTime: now, EndTime: now.Add(time.Minute)})
+	board.Add(SCData{ID: 3, Price: Yuan(30), StartTime: now.Add(-time.Hour), EndTime: now.Add(-time.Second)})
+	list := board.List()
+	if len(list) != 2 || list[0].ID != 2 || list[1].ID != 1 {
+		t.Error("unexpected board: ", list)
+	}
+	if _, ok := board.Translate(1, "こんにちは"); !ok {
+		t.Error("Translate failed")
+	}
+	board.Add(SCData{ID: 1, Price: Yuan(30), StartTime: now, EndTime: now.Add(time.Minute)})
+	if board.List()[1].MessageTrans != "こんにちは" {
+		t.Error("Add should keep the merged translation")
+	}
+	if removed := board.Delete(2, 4); len(removed) != 1 || len(board.List()) != 1 {
+		t.Error("Delete failed")
+	}
+}
+
+func TestSCBoardExpire(t *testing.T) {
+	board := NewSCBoard()
+	now := time.Now()
+	board.Add(SCData{ID: 1, Price: Yuan(30), StartTime: now, EndTime: now.Add(time.Minute)})
+	board.Add(SCData{ID: 2, Price: Yuan(30), StartTime: now.Add(-time.Hour), EndTime: now.Add(-time.Second)})
+	if len(board.List()) != 1 {
+		t.Fatal("List should hide ended SC")
+	}
+	if expired := board.Expire(now); len(expired) != 1 || expired[0].ID != 2 {
+		t.Fatal("unexpected expired: ", expired)
+	}
+	if expired := board.Expire(now); len(expired) != 0 {
+		t.Fatal("SC expired twice: ", expired)
+	}
+	if expired := board.Expire(now.Add(time.Minute)); len(expired) != 1 || expired[0].ID != 1 {
+		t.Fatal("unexpected expired: ", expired)
+	}
+}
+
+func TestHandleSCDeleteUnknown(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr
+	now := time.Now()
+	b.scBoard.Add(SCData{ID: 1, Price: Yuan(30), StartTime: now, EndTime: now.Add(time.Minute)})
+	var msg SuperChatMessageDelete
+	msg.Data.IDs = []int{1, 2}
+	b.HandleSCDelete(msg)
+	e := (<-b.dataChan).(SCDeleteData)
+	if len(e.IDs) != 2 || len(e.Removed) != 1 || e.Removed[0] != 1 {
+		t.Fatal("unexpected delete event: ", e)
+	}
+	msg.Data.IDs = []int{3}
+	b.HandleSCDelete(msg)
+	if e := (<-b.dataChan).(SCDeleteData); len(e.IDs) != 1 || e.IDs[0] != 3 || len(e.Removed) != 0 {
+		t.Fatal("delete for an SC not on the board should still be emitted: ", e)
+	}
+}
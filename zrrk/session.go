@@ -0,0 +1,192 @@
+package zrrk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Session struct {
+	// ID 已知 live_key 时与 live_key 相同，否则由房间号和开播时间生成。
+	// 连接时同步的场次没有 live_key，之后收到同一场次的 LIVE 时改用其中的 live_key。
+	ID            string      `json:"id"`
+	RoomID        int         `json:"roomid"`
+	LiveKey       string      `json:"live_key"`
+	SubSessionKey string      `json:"sub_session_key"`
+	StartTime     time.Time   `json:"start_time"`
+	EndTime       time.Time   `json:"end_time"`
+	IsLive        bool        `json:"is_live"`
+	CoStreams     []CoStream  `json:"co_streams"`
+	Milestones    []Milestone `json:"milestones"`
+}
+
+// CoStream 为一次连麦，连麦消息中只有对方的 UID 和昵称。
+type CoStream struct {
+	Type        string    `json:"type"`
+	ChannelID   string    `json:"channel_id"`
+	PartnerUID  int       `json:"partner_uid"`
+	PartnerName string    `json:"partner_name"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+// Milestone 为场次时间线上值得标记的时刻，Type 可以是 MILESTONE_* 或插件自定义的类型。
+type Milestone struct {
+	Type string    `json:"type"`
+	Text string    `json:"text"`
+	Time time.Time `json:"time"`
+}
+
+func (s *Session) Duration() time.Duration {
+	if s.StartTime.IsZero() {
+		return 0
+	}
+	if s.IsLive || s.EndTime.IsZero() {
+		return time.Since(s.StartTime)
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+type SessionTracker struct {
+	lock    sync.RWMutex
+	current Session
+}
+
+func NewSessionTracker() *SessionTracker {
+	return &SessionTracker{}
+}
+
+// Start 记录一次开播，返回当前场次以及是否开始了新的场次。
+// 同一场直播往往会收到多条 LIVE，重复的消息只会补全缺失的字段，
+// 缺少 live_key 的场次会采用收到的 live_key 作为 ID。
+func (t *SessionTracker) Start(s Session) (Session, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if s.StartTime.IsZero() {
+		s.StartTime = time.Now()
+	}
+	if t.current.IsLive && t.isSameSession(s) {
+		if t.current.LiveKey == "" && s.LiveKey != "" {
+			t.current.LiveKey = s.LiveKey
+			t.current.ID = s.LiveKey
+		}
+		if s.SubSessionKey != "" {
+			t.current.SubSessionKey = s.SubSessionKey
+		}
+		return t.copy(), false
+	}
+	s.IsLive = true
+	s.EndTime = time.Time{}
+	s.ID = s.LiveKey
+	if s.ID == "" {
+		s.ID = fmt.Sprintf("%d-%d", s.RoomID, s.StartTime.Unix())
+	}
+	s.CoStreams = nil
+	s.Milestones = nil
+	t.current = s
+	return t.copy(), true
+}
+
+func (t *SessionTracker) isSameSession(s Session) bool {
+	if s.LiveKey != "" && t.current.LiveKey != "" {
+		return s.LiveKey == t.current.LiveKey
+	}
+	diff := s.StartTime.Sub(t.current.StartTime)
+	return diff < time.Minute && diff > -time.Minute
+}
+
+// End 记录一次下播，返回结束的场次以及此前是否处于直播中。
+func (t *SessionTracker) End(endTime time.Time) (Session, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.current.IsLive {
+		return t.copy(), false
+	}
+	t.current.IsLive = false
+	t.current.EndTime = endTime
+	for i := range t.current.CoStreams {
+		if t.current.CoStreams[i].EndTime.IsZero() {
+			t.current.CoStreams[i].EndTime = endTime
+		}
+	}
+	return t.copy(), true
+}
+
+func (t *SessionTracker) Current() Session {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.copy()
+}
+
+func (t *SessionTracker) copy() Session {
+	s := t.current
+	s.CoStreams = append([]CoStream(nil), t.current.CoStreams...)
+	s.Milestones = append([]Milestone(nil), t.current.Milestones...)
+	return s
+}
+
+// StartCoStream 在当前场次中记录一次连麦的开始，返回是否记录。
+// 未开播时以及同一频道的连麦尚未结束时不记录。
+func (t *SessionTracker) StartCoStream(cs CoStream) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.current.IsLive {
+		return false
+	}
+	for _, c := range t.current.CoStreams {
+		if c.ChannelID == cs.ChannelID && c.EndTime.IsZero() {
+			return false
+		}
+	}
+	t.current.CoStreams = append(t.current.CoStreams, cs)
+	return true
+}
+
+// EndCoStream 结束当前场次中的连麦，返回被结束的连麦。
+// channelID 为空时结束该类型最近一次未结束的连麦。
+func (t *SessionTracker) EndCoStream(typ, channelID string, endTime time.Time) (CoStream, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i := len(t.current.CoStreams) - 1; i >= 0; i-- {
+		c := &t.current.CoStreams[i]
+		if !c.EndTime.IsZero() || c.Type != typ {
+			continue
+		}
+		if channelID != "" && c.ChannelID != channelID {
+			continue
+		}
+		c.EndTime = endTime
+		return *c, true
+	}
+	return CoStream{}, false
+}
+
+// Mark 在当前场次的时间线上记录一个里程碑，未开播时不记录并返回 false。
+func (t *SessionTracker) Mark(m Milestone) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.current.IsLive {
+		return false
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	t.current.Milestones = append(t.current.Milestones, m)
+	return true
+}
+
+func (t *SessionTracker) IsLive() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.current.IsLive
+}
+
+// ID 返回当前事件应归属的场次，未开播时为空。
+func (t *SessionTracker) ID() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if !t.current.IsLive {
+		return ""
+	}
+	return t.current.ID
+}
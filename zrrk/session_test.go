@@ -0,0 +1,123 @@
+package zrrk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionTracker(t *testing.T) {
+	tracker := NewSessionTracker()
+	if tracker.IsLive() || tracker.ID() != "" {
+		t.Error("new tracker should not be live")
+	}
+	start := time.Unix(1661460000, 0)
+	s, started := tracker.Start(Session{RoomID: 1, LiveKey: "key", StartTime: start})
+	if !started || s.ID != "key" {
+		t.Error("Start failed")
+	}
+	if _, started = tracker.Start(Session{RoomID: 1, LiveKey: "key", SubSessionKey: "sub"}); started {
+		t.Error("duplicate LIVE should not start a new session")
+	}
+	if tracker.Current().SubSessionKey != "sub" {
+		t.Error("duplicate LIVE should fill sub session key")
+	}
+	s, ended := tracker.End(start.Add(time.Hour))
+	if !ended || s.Duration() != time.Hour || tracker.ID() != "" {
+		t.Error("End failed")
+	}
+	if _, ended = tracker.End(time.Now()); ended {
+		t.Error("End should only report the first transition")
+	}
+}
+
+func TestSessionTrackerWithoutLiveKey(t *testing.T) {
+	tracker := NewSessionTracker()
+	start := time.Unix(1661460000, 0)
+	s, _ := tracker.Start(Session{RoomID: 1, StartTime: start})
+	if s.ID != "1-1661460000" {
+		t.Error("unexpected generated session id: ", s.ID)
+	}
+	s, started := tracker.Start(Session{RoomID: 1, LiveKey: "key", StartTime: start.Add(time.Second)})
+	if started || s.ID != "key" || s.LiveKey != "key" {
+		t.Error("LIVE for a seeded session should adopt its live_key: ", s.ID)
+	}
+	if s, started = tracker.Start(Session{RoomID: 1, LiveKey: "key"}); started || s.ID != "key" {
+		t.Error("later LIVE should keep the adopted id: ", s.ID)
+	}
+}
+
+func TestSessionTrackerMark(t *testing.T) {
+	tracker := NewSessionTracker()
+	if tracker.Mark(Milestone{Type: MILESTONE_ROOM_SKIN}) {
+		t.Fatal("should not mark before live")
+	}
+	tracker.Start(Session{RoomID: 1, LiveKey: "a"})
+	if !tracker.Mark(Milestone{Type: MILESTONE_ROOM_SKIN}) {
+		t.Fatal("should mark while live")
+	}
+	s := tracker.Current()
+	if len(s.Milestones) != 1 || s.Milestones[0].Time.IsZero() {
+		t.Fatalf("unexpected milestones: %v", s.Milestones)
+	}
+	tracker.Start(Session{RoomID: 1, LiveKey: "b"})
+	if len(tracker.Current().Milestones) != 0 {
+		t.Fatal("new session should not inherit milestones")
+	}
+}
+
+func TestBotMarkDoesNotBlock(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr + 1
+	b.session.Start(Session{RoomID: 1, LiveKey: "a"})
+	for len(b.dataChan) < cap(b.dataChan) {
+		b.dataChan <- DanmakuData{}
+	}
+	done := make(chan bool)
+	go func() {
+		done <- b.Mark(Milestone{Type: "custom"})
+	}()
+	select {
+	case ok := <-done:
+		if !ok || len(b.session.Current().Milestones) != 1 {
+			t.Fatal("milestone should still be recorded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Mark blocked on a full event channel")
+	}
+}
+
+func TestDiscoverDoesNotBlock(t *testing.T) {
+	b := New()
+	b.Lock = &sync.Mutex{}
+	b.LogLevel = LogErr
+	release := make(chan struct{})
+	got := make(chan int, discoveryBuffer*2)
+	b.OnDiscover = func(roomID int, source string) {
+		<-release
+		got <- roomID
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.deliverDiscoveries(ctx)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < discoveryBuffer*2; i++ {
+			b.discover(i+2, DISCOVER_PK)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("discover blocked on a slow OnDiscover")
+	}
+	close(release)
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("discovery was not delivered")
+	}
+}
@@ -0,0 +1,45 @@
+package zrrk
+
+import (
+	"io/ioutil"
+	"log"
+)
+
+// Sink 接收插件的输出，例如 todayrp 的回复和插件说明。
+type Sink interface {
+	Write(roomID int, msg string) error
+}
+
+// FileSink 将最新的一条输出覆盖写入文件，供直播软件读取。
+type FileSink struct {
+	Path string `json:"path"`
+}
+
+func (s *FileSink) Write(roomID int, msg string) error {
+	return ioutil.WriteFile(s.Path, []byte(msg), 0644)
+}
+
+// LogSink 将输出写入日志。
+type LogSink struct{}
+
+func (s *LogSink) Write(roomID int, msg string) error {
+	log.Printf("[ROOM %10d] 输出: %s", roomID, msg)
+	return nil
+}
+
+// AddSink 添加输出，没有添加任何输出时使用 WriteToFile。
+func (b *Bot) AddSink(sink Sink) {
+	b.sinks = append(b.sinks, sink)
+}
+
+func (b *Bot) output(msg string) {
+	if len(b.sinks) == 0 {
+		WriteToFile(msg)
+		return
+	}
+	for _, sink := range b.sinks {
+		if err := sink.Write(b.RoomID, msg); err != nil {
+			b.ERROR("输出失败: ", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package zrrk
+
+import (
+	"reflect"
+	"strings"
+)
+
+// EventFilter 判断事件是否需要交给插件。
+type EventFilter func(event interface{}) bool
+
+// Subscription 为插件订阅的一种事件，所有 Filters 都通过时才会交给插件。
+type Subscription struct {
+	Kind    string
+	Filters []EventFilter
+}
+
+// SubscribingPlugin 由只关心部分事件的插件实现，未实现的插件会收到所有事件。
+type SubscribingPlugin interface {
+	Subscriptions() []Subscription
+}
+
+// On 订阅与 event 同类型的事件，例如 On(GiftData{}, MinPrice(Yuan(1)))。
+func On(event interface{}, filters ...EventFilter) Subscription {
+	return Subscription{Kind: EventKind(event), Filters: filters}
+}
+
+func (s *Subscription) match(event interface{}) bool {
+	for _, filter := range s.Filters {
+		if !filter(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// InRoom 只接受指定直播间的事件，适用于共享的插件。
+func InRoom(roomIDs ...int) EventFilter {
+	return func(event interface{}) bool {
+		v := reflect.Indirect(reflect.ValueOf(event))
+		if v.Kind() != reflect.Struct {
+			return false
+		}
+		field := v.FieldByName("RoomID")
+		if !field.IsValid() || field.Kind() != reflect.Int {
+			return false
+		}
+		for _, id := range roomIDs {
+			if int(field.Int()) == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MinPrice 只接受总价值不低于 min 的付费事件，没有价值的事件不受影响。
+func MinPrice(min Money) EventFilter {
+	return func(event interface{}) bool {
+		var total Money
+		switch e := event.(type) {
+		case GiftData:
+			total = e.Gift.Total()
+		case ComboData:
+			total = e.Total
+		case SCData:
+			total = e.Price
+		case GuardEvent:
+			total = e.Price.Mul(e.Num)
+		default:
+			return true
+		}
+		return total.Gold() >= min.Gold()
+	}
+}
+
+// Summarized 只接受代替了 GiftData 的连击汇总，即 Summary 为 true 的 ComboData，其余事件不受影响。
+// 统计收入的插件同时订阅 GiftData 和 On(ComboData{}, Summarized())，在任何连击模式下都只会记录一次。
+func Summarized() EventFilter {
+	return func(event interface{}) bool {
+		if e, ok := event.(ComboData); ok {
+			return e.Summary
+		}
+		return true
+	}
+}
+
+// Keyword 只接受文本中包含任意一个关键词的弹幕和 SC。
+func Keyword(words ...string) EventFilter {
+	return func(event interface{}) bool {
+		var text string
+		switch e := event.(type) {
+		case DanmakuData:
+			text = e.Text
+		case SCData:
+			text = e.Text
+		default:
+			return true
+		}
+		for _, word := range words {
+			if strings.Contains(text, word) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// commandEvents 为只用于发出事件的命令，没有插件订阅这些事件时不再解析。
+// 会更新机器人自身状态的命令（开播、房管、SC 列表、高能榜等）不在其中，总是会被解析。
+var commandEvents = map[string][]string{
+	"DANMU_MSG":              {"DanmakuData"},
+	"SEND_GIFT":              {"GiftData", "ComboData"},
+	"COMBO_SEND":             {"ComboData"},
+	"INTERACT_WORD":          {"InteractData"},
+	"ENTRY_EFFECT":           {"EntryEffectData"},
+	"NOTICE_MSG":             {"NoticeData"},
+	"GUARD_BUY":              {"GuardEvent"},
+	"USER_TOAST_MSG":         {"GuardEvent"},
+	"WATCHED_CHANGE":         {"WatchedData"},
+	"LIKE_INFO_V3_UPDATE":    {"LikeData"},
+	"ROOM_BLOCK_MSG":         {"ModerationData"},
+	"ROOM_SILENT_ON":         {"ModerationData"},
+	"ROOM_SILENT_OFF":        {"ModerationData"},
+	"HOT_BUY_NUM":            {"HotBuyData"},
+	"GOTO_BUY_FLOW":          {"GotoBuyData"},
+	"SHOPPING_CART_SHOW":     {"ShoppingCartData"},
+	"SELECTED_GOODS_INFO":    {"GoodsData"},
+	"SHOPPING_EXPLAIN_CARD":  {"GoodsData"},
+	"SHOPPING_BUBBLES_STYLE": {"ShoppingBubblesData"},
+	"LIVE_INTERACTIVE_GAME":  {"GameActionData"},
+}
+
+// subscribedKinds 汇总所有插件订阅的事件，有插件订阅所有事件时返回 nil。
+func (b *Bot) subscribedKinds() map[string]bool {
+	kinds := map[string]bool{}
+	for _, e := range b.plugins {
+		if e.subs == nil {
+			return nil
+		}
+		for _, s := range e.subs {
+			kinds[s.Kind] = true
+		}
+	}
+	return kinds
+}
+
+// wantsCommand 判断命令是否需要解析。
+func (b *Bot) wantsCommand(cmd string) bool {
+	if b.kinds == nil {
+		return true
+	}
+	events, ok := commandEvents[cmd]
+	if !ok {
+		return true
+	}
+	if cmd == "NOTICE_MSG" && b.OnDiscover != nil {
+		return true
+	}
+	for _, kind := range events {
+		if b.kinds[kind] {
+			return true
+		}
+	}
+	return false
+}
@@ -56,7 +56,11 @@ func IsSameDay(t time.Time) bool {
 }
 
 func NewClient() (*http.Client, error) {
-	proxyURL := os.Getenv("PROXY_URL")
+	return NewProxyClient(os.Getenv("PROXY_URL"))
+}
+
+// NewProxyClient 返回通过 proxyURL 访问的客户端，proxyURL 为空时不使用代理。
+func NewProxyClient(proxyURL string) (*http.Client, error) {
 	if proxyURL == "" {
 		return http.DefaultClient, nil
 	}
@@ -76,6 +80,10 @@ func GetResponse(targetURL string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	return getWithClient(client, targetURL)
+}
+
+func getWithClient(client *http.Client, targetURL string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", targetURL, nil)
 	if err != nil {
 		return nil, err
@@ -194,3 +202,39 @@ func ContainStrings(s ...string) bool {
 	}
 	return false
 }
+
+var markupReplacer = strings.NewReplacer("<%", "", "%>", "")
+
+// StripMarkup 去掉文案中用于高亮的 <% %> 标记。
+func StripMarkup(s string) string {
+	return markupReplacer.Replace(s)
+}
+
+// markedText 返回第一段被 <% %> 标记的文本，通常是用户名。
+func markedText(s string) string {
+	start := strings.Index(s, "<%")
+	if start < 0 {
+		return ""
+	}
+	s = s[start+2:]
+	end := strings.Index(s, "%>")
+	if end < 0 {
+		return ""
+	}
+	return s[:end]
+}
+
+// unixOrNow 将消息中的秒级时间戳转为时间，缺失时使用当前时间。
+func unixOrNow(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Now()
+	}
+	return time.Unix(ts, 0)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -16,3 +16,9 @@ func TestContainStrings(t *testing.T) {
 		t.Error("ContainStrings failed")
 	}
 }
+
+func TestStripMarkup(t *testing.T) {
+	if StripMarkup("恭喜 <%某某%> 成为高能榜") != "恭喜 某某 成为高能榜" {
+		t.Error("StripMarkup failed")
+	}
+}